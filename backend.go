@@ -0,0 +1,118 @@
+package main
+
+// import libraries..
+import (
+	"context"
+	"net/http"
+	"os"
+
+	"github.com/oracle/oci-go-sdk/common"
+	"github.com/oracle/oci-go-sdk/containerengine"
+	"github.com/oracle/oci-go-sdk/core"
+	"github.com/oracle/oci-go-sdk/example/helpers"
+	"github.com/oracle/oci-go-sdk/identity"
+)
+
+// okeBackend is the subset of the OKE container engine api used by okectl..
+// containerengine.ContainerEngineClient satisfies it, as does fakeBackend for offline use..
+type okeBackend interface {
+	CreateCluster(ctx context.Context, request containerengine.CreateClusterRequest) (containerengine.CreateClusterResponse, error)
+	DeleteCluster(ctx context.Context, request containerengine.DeleteClusterRequest) (containerengine.DeleteClusterResponse, error)
+	GetCluster(ctx context.Context, request containerengine.GetClusterRequest) (containerengine.GetClusterResponse, error)
+	UpdateCluster(ctx context.Context, request containerengine.UpdateClusterRequest) (containerengine.UpdateClusterResponse, error)
+	ListClusters(ctx context.Context, request containerengine.ListClustersRequest) (containerengine.ListClustersResponse, error)
+	CreateNodePool(ctx context.Context, request containerengine.CreateNodePoolRequest) (containerengine.CreateNodePoolResponse, error)
+	ListNodePools(ctx context.Context, request containerengine.ListNodePoolsRequest) (containerengine.ListNodePoolsResponse, error)
+	UpdateNodePool(ctx context.Context, request containerengine.UpdateNodePoolRequest) (containerengine.UpdateNodePoolResponse, error)
+	DeleteNodePool(ctx context.Context, request containerengine.DeleteNodePoolRequest) (containerengine.DeleteNodePoolResponse, error)
+	GetNodePool(ctx context.Context, request containerengine.GetNodePoolRequest) (containerengine.GetNodePoolResponse, error)
+	CreateKubeconfig(ctx context.Context, request containerengine.CreateKubeconfigRequest) (containerengine.CreateKubeconfigResponse, error)
+	GetWorkRequest(ctx context.Context, request containerengine.GetWorkRequestRequest) (containerengine.GetWorkRequestResponse, error)
+	ListWorkRequestErrors(ctx context.Context, request containerengine.ListWorkRequestErrorsRequest) (containerengine.ListWorkRequestErrorsResponse, error)
+	ListWorkRequestLogs(ctx context.Context, request containerengine.ListWorkRequestLogsRequest) (containerengine.ListWorkRequestLogsResponse, error)
+	DeleteWorkRequest(ctx context.Context, request containerengine.DeleteWorkRequestRequest) (containerengine.DeleteWorkRequestResponse, error)
+	GetClusterOptions(ctx context.Context, request containerengine.GetClusterOptionsRequest) (containerengine.GetClusterOptionsResponse, error)
+	GetNodePoolOptions(ctx context.Context, request containerengine.GetNodePoolOptionsRequest) (containerengine.GetNodePoolOptionsResponse, error)
+}
+
+// create oke backend..
+// backendType "oci" talks to the live service, "fake" simulates it in memory..
+func newOkeBackend(backendType string) okeBackend {
+	if backendType == "fake" {
+		return newFakeBackend(os.Getenv("OKECTL_FAKE_STATE"))
+	}
+
+	c, clerr := containerengine.NewContainerEngineClientWithConfigurationProvider(common.DefaultConfigProvider())
+	helpers.FatalIfError(clerr)
+
+	return c
+}
+
+// whether an error is the service's 404 for a missing resource..
+// the fake backend reports missing clusters & node pools the same way..
+func isNotFound(err error) bool {
+	serviceError, ok := err.(interface{ GetHTTPStatusCode() int })
+	return ok && serviceError.GetHTTPStatusCode() == http.StatusNotFound
+}
+
+// computeBackend is the subset of the OCI compute api used by okectl..
+type computeBackend interface {
+	TerminateInstance(ctx context.Context, request core.TerminateInstanceRequest) (core.TerminateInstanceResponse, error)
+	ListVnicAttachments(ctx context.Context, request core.ListVnicAttachmentsRequest) (core.ListVnicAttachmentsResponse, error)
+}
+
+// create compute backend..
+// a fake oke backend also simulates compute, so worker node instances are shared with it..
+func newComputeBackend(oke okeBackend) computeBackend {
+	if fake, ok := oke.(*fakeBackend); ok {
+		return fake
+	}
+
+	c, clerr := core.NewComputeClientWithConfigurationProvider(common.DefaultConfigProvider())
+	helpers.FatalIfError(clerr)
+
+	return c
+}
+
+// networkBackend is the subset of the OCI virtual network api used by okectl..
+type networkBackend interface {
+	GetVnic(ctx context.Context, request core.GetVnicRequest) (core.GetVnicResponse, error)
+	CreateVcn(ctx context.Context, request core.CreateVcnRequest) (core.CreateVcnResponse, error)
+	GetVcn(ctx context.Context, request core.GetVcnRequest) (core.GetVcnResponse, error)
+	CreateInternetGateway(ctx context.Context, request core.CreateInternetGatewayRequest) (core.CreateInternetGatewayResponse, error)
+	CreateRouteTable(ctx context.Context, request core.CreateRouteTableRequest) (core.CreateRouteTableResponse, error)
+	CreateSecurityList(ctx context.Context, request core.CreateSecurityListRequest) (core.CreateSecurityListResponse, error)
+	CreateSubnet(ctx context.Context, request core.CreateSubnetRequest) (core.CreateSubnetResponse, error)
+	GetSubnet(ctx context.Context, request core.GetSubnetRequest) (core.GetSubnetResponse, error)
+}
+
+// create network backend..
+// a fake oke backend also simulates networking..
+func newNetworkBackend(oke okeBackend) networkBackend {
+	if fake, ok := oke.(*fakeBackend); ok {
+		return fake
+	}
+
+	c, clerr := core.NewVirtualNetworkClientWithConfigurationProvider(common.DefaultConfigProvider())
+	helpers.FatalIfError(clerr)
+
+	return c
+}
+
+// identityBackend is the subset of the OCI identity api used by okectl..
+type identityBackend interface {
+	ListAvailabilityDomains(ctx context.Context, request identity.ListAvailabilityDomainsRequest) (identity.ListAvailabilityDomainsResponse, error)
+}
+
+// create identity backend..
+// a fake oke backend also simulates availability domains..
+func newIdentityBackend(oke okeBackend) identityBackend {
+	if fake, ok := oke.(*fakeBackend); ok {
+		return fake
+	}
+
+	c, clerr := identity.NewIdentityClientWithConfigurationProvider(common.DefaultConfigProvider())
+	helpers.FatalIfError(clerr)
+
+	return c
+}
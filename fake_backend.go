@@ -0,0 +1,1044 @@
+package main
+
+// import libraries..
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/common"
+	"github.com/oracle/oci-go-sdk/containerengine"
+	"github.com/oracle/oci-go-sdk/core"
+	"github.com/oracle/oci-go-sdk/identity"
+)
+
+// fakeBackend simulates the OKE api in memory, for offline runs & CI..
+// work requests progress ACCEPTED -> IN_PROGRESS -> SUCCEEDED on successive polls, & nodes
+// move from CREATING to ACTIVE once they have been observed by GetNodePool..
+// when statePath is set, state is loaded from & saved to that file so separate okectl runs share it..
+type fakeBackend struct {
+	mu        sync.Mutex
+	statePath string
+	state     fakeState
+	// work request operation types made to fail, from OKECTL_FAKE_FAIL..
+	failOperations map[string]bool
+	// interval between work request polls, from OKECTL_FAKE_DELAY..
+	pollDelay time.Duration
+}
+
+// fakeState is the simulated tenancy..
+type fakeState struct {
+	Sequence     int                                     `json:"sequence"`
+	Clusters     map[string]*containerengine.Cluster     `json:"clusters"`
+	NodePools    map[string]*containerengine.NodePool    `json:"nodePools"`
+	WorkRequests map[string]*containerengine.WorkRequest `json:"workRequests"`
+	// updates awaiting completion of their work request, by work request id..
+	PendingNodePoolUpdates map[string]containerengine.UpdateNodePoolDetails `json:"pendingNodePoolUpdates"`
+	PendingClusterUpdates  map[string]containerengine.UpdateClusterDetails  `json:"pendingClusterUpdates"`
+	// work request errors & logs, by work request id..
+	WorkRequestErrors map[string][]containerengine.WorkRequestError    `json:"workRequestErrors"`
+	WorkRequestLogs   map[string][]containerengine.WorkRequestLogEntry `json:"workRequestLogs"`
+	// networking, by id..
+	Vcns             map[string]*core.Vcn             `json:"vcns"`
+	InternetGateways map[string]*core.InternetGateway `json:"internetGateways"`
+	RouteTables      map[string]*core.RouteTable      `json:"routeTables"`
+	SecurityLists    map[string]*core.SecurityList    `json:"securityLists"`
+	Subnets          map[string]*core.Subnet          `json:"subnets"`
+}
+
+// kubernetes versions offered by the fake backend..
+var fakeKubernetesVersions = []string{"v1.10.3", "v1.10.11", "v1.11.1", "v1.11.5", "v1.12.6", "v1.12.7", "v1.13.5"}
+
+// node images & shapes offered by the fake backend..
+var fakeNodeImages = []string{"Oracle-Linux-7.4", "Oracle-Linux-7.5", "Oracle-Linux-7.6"}
+var fakeNodeShapes = []string{"VM.Standard1.1", "VM.Standard1.2", "VM.Standard1.4", "VM.Standard2.1", "VM.Standard2.2", "VM.Standard2.4", "VM.Standard2.8", "BM.Standard2.52"}
+
+// lifecycle details of nodes removed by a scale down, listed until the next poll..
+const fakeNodeTerminating = "terminating compute instance"
+
+// fakeNotFoundError reports a missing resource with the service's 404..
+type fakeNotFoundError string
+
+func (e fakeNotFoundError) Error() string          { return string(e) }
+func (e fakeNotFoundError) GetHTTPStatusCode() int { return http.StatusNotFound }
+
+// create fake backend..
+// OKECTL_FAKE_FAIL lists work request operation types to fail - e.g. NODEPOOL_CREATE,CLUSTER_DELETE - or SUBNET_CREATE..
+// OKECTL_FAKE_DELAY sets the interval between work request polls - e.g. 2s - leaving time to interrupt a wait..
+func newFakeBackend(statePath string) *fakeBackend {
+	f := &fakeBackend{statePath: statePath, failOperations: map[string]bool{}}
+	for _, operationType := range strings.Split(os.Getenv("OKECTL_FAKE_FAIL"), ",") {
+		if operationType != "" {
+			f.failOperations[strings.TrimSpace(operationType)] = true
+		}
+	}
+	if delay := os.Getenv("OKECTL_FAKE_DELAY"); delay != "" {
+		pollDelay, err := time.ParseDuration(delay)
+		if err != nil {
+			logln("OKECTL :: Error reading OKECTL_FAKE_DELAY:", err)
+		}
+		f.pollDelay = pollDelay
+	}
+	f.state.Clusters = map[string]*containerengine.Cluster{}
+	f.state.NodePools = map[string]*containerengine.NodePool{}
+	f.state.WorkRequests = map[string]*containerengine.WorkRequest{}
+	f.state.PendingNodePoolUpdates = map[string]containerengine.UpdateNodePoolDetails{}
+	f.state.PendingClusterUpdates = map[string]containerengine.UpdateClusterDetails{}
+	f.state.WorkRequestErrors = map[string][]containerengine.WorkRequestError{}
+	f.state.WorkRequestLogs = map[string][]containerengine.WorkRequestLogEntry{}
+	f.state.Vcns = map[string]*core.Vcn{}
+	f.state.InternetGateways = map[string]*core.InternetGateway{}
+	f.state.RouteTables = map[string]*core.RouteTable{}
+	f.state.SecurityLists = map[string]*core.SecurityList{}
+	f.state.Subnets = map[string]*core.Subnet{}
+
+	// load state from a previous run..
+	if statePath != "" {
+		content, err := ioutil.ReadFile(statePath)
+		if err == nil {
+			err = json.Unmarshal(content, &f.state)
+			if err != nil {
+				logln("OKECTL :: Error reading fake backend state:", err)
+			}
+		}
+	}
+
+	return f
+}
+
+// create cluster..
+func (f *fakeBackend) CreateCluster(ctx context.Context, request containerengine.CreateClusterRequest) (containerengine.CreateClusterResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	clusterId := f.nextId("cluster")
+	f.state.Clusters[clusterId] = &containerengine.Cluster{
+		Id:                common.String(clusterId),
+		Name:              request.Name,
+		CompartmentId:     request.CompartmentId,
+		VcnId:             request.VcnId,
+		KubernetesVersion: request.KubernetesVersion,
+		Options:           request.Options,
+		LifecycleState:    containerengine.ClusterLifecycleStateCreating,
+		Endpoints: &containerengine.ClusterEndpoints{
+			Kubernetes: common.String(strings.TrimPrefix(clusterId, "ocid1.cluster.oc1.") + ".fake.oke.local:6443"),
+		},
+		AvailableKubernetesUpgrades: fakeKubernetesUpgrades(derefString(request.KubernetesVersion)),
+	}
+	workRequestId := f.submitWorkRequest(containerengine.WorkRequestOperationTypeClusterCreate, request.CompartmentId, "CLUSTER", clusterId)
+	f.save()
+
+	return containerengine.CreateClusterResponse{OpcWorkRequestId: common.String(workRequestId)}, nil
+}
+
+// delete cluster..
+func (f *fakeBackend) DeleteCluster(ctx context.Context, request containerengine.DeleteClusterRequest) (containerengine.DeleteClusterResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	cluster, err := f.cluster(request.ClusterId)
+	if err != nil {
+		return containerengine.DeleteClusterResponse{}, err
+	}
+	cluster.LifecycleState = containerengine.ClusterLifecycleStateDeleting
+	workRequestId := f.submitWorkRequest(containerengine.WorkRequestOperationTypeClusterDelete, cluster.CompartmentId, "CLUSTER", *cluster.Id)
+	f.save()
+
+	return containerengine.DeleteClusterResponse{OpcWorkRequestId: common.String(workRequestId)}, nil
+}
+
+// update cluster..
+// the update is held until its work request completes..
+func (f *fakeBackend) UpdateCluster(ctx context.Context, request containerengine.UpdateClusterRequest) (containerengine.UpdateClusterResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	cluster, err := f.cluster(request.ClusterId)
+	if err != nil {
+		return containerengine.UpdateClusterResponse{}, err
+	}
+	if request.KubernetesVersion != nil && *request.KubernetesVersion != derefString(cluster.KubernetesVersion) {
+		offered := false
+		for _, version := range cluster.AvailableKubernetesUpgrades {
+			if version == *request.KubernetesVersion {
+				offered = true
+			}
+		}
+		if !offered {
+			return containerengine.UpdateClusterResponse{}, fmt.Errorf("fake backend: cluster %s can not be upgraded to %s", *cluster.Id, *request.KubernetesVersion)
+		}
+	}
+	cluster.LifecycleState = containerengine.ClusterLifecycleStateUpdating
+	workRequestId := f.submitWorkRequest(containerengine.WorkRequestOperationTypeClusterUpdate, cluster.CompartmentId, "CLUSTER", *cluster.Id)
+	f.state.PendingClusterUpdates[workRequestId] = request.UpdateClusterDetails
+	f.save()
+
+	return containerengine.UpdateClusterResponse{OpcWorkRequestId: common.String(workRequestId)}, nil
+}
+
+// get cluster..
+func (f *fakeBackend) GetCluster(ctx context.Context, request containerengine.GetClusterRequest) (containerengine.GetClusterResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	cluster, err := f.cluster(request.ClusterId)
+	if err != nil {
+		return containerengine.GetClusterResponse{}, err
+	}
+
+	return containerengine.GetClusterResponse{Cluster: *cluster}, nil
+}
+
+// list clusters..
+func (f *fakeBackend) ListClusters(ctx context.Context, request containerengine.ListClustersRequest) (containerengine.ListClustersResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// filter..
+	clusterIds := []string{}
+	for clusterId, cluster := range f.state.Clusters {
+		if derefString(cluster.CompartmentId) != derefString(request.CompartmentId) {
+			continue
+		}
+		if request.Name != nil && derefString(cluster.Name) != *request.Name {
+			continue
+		}
+		if len(request.LifecycleState) > 0 {
+			stateMatch := false
+			for _, lifecycleState := range request.LifecycleState {
+				if string(lifecycleState) == string(cluster.LifecycleState) {
+					stateMatch = true
+				}
+			}
+			if !stateMatch {
+				continue
+			}
+		}
+		clusterIds = append(clusterIds, clusterId)
+	}
+	sort.Strings(clusterIds)
+
+	// page..
+	start, end, nextPage := fakePage(len(clusterIds), request.Page, request.Limit)
+	resp := containerengine.ListClustersResponse{Items: []containerengine.ClusterSummary{}, OpcNextPage: nextPage}
+	for _, clusterId := range clusterIds[start:end] {
+		cluster := f.state.Clusters[clusterId]
+		resp.Items = append(resp.Items, containerengine.ClusterSummary{
+			Id:                          cluster.Id,
+			Name:                        cluster.Name,
+			CompartmentId:               cluster.CompartmentId,
+			VcnId:                       cluster.VcnId,
+			KubernetesVersion:           cluster.KubernetesVersion,
+			Options:                     cluster.Options,
+			Metadata:                    cluster.Metadata,
+			LifecycleState:              containerengine.ClusterSummaryLifecycleStateEnum(cluster.LifecycleState),
+			LifecycleDetails:            cluster.LifecycleDetails,
+			Endpoints:                   cluster.Endpoints,
+			AvailableKubernetesUpgrades: cluster.AvailableKubernetesUpgrades,
+		})
+	}
+
+	return resp, nil
+}
+
+// create nodepool..
+func (f *fakeBackend) CreateNodePool(ctx context.Context, request containerengine.CreateNodePoolRequest) (containerengine.CreateNodePoolResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	cluster, err := f.cluster(request.ClusterId)
+	if err != nil {
+		return containerengine.CreateNodePoolResponse{}, err
+	}
+	if cluster.LifecycleState != containerengine.ClusterLifecycleStateActive {
+		return containerengine.CreateNodePoolResponse{}, fmt.Errorf("fake backend: cluster %s is %s, not ACTIVE", *cluster.Id, cluster.LifecycleState)
+	}
+
+	nodePoolId := f.nextId("nodepool")
+	f.state.NodePools[nodePoolId] = &containerengine.NodePool{
+		Id:                common.String(nodePoolId),
+		CompartmentId:     request.CompartmentId,
+		ClusterId:         request.ClusterId,
+		Name:              request.Name,
+		KubernetesVersion: request.KubernetesVersion,
+		NodeImageId:       common.String("ocid1.image.oc1.fake." + strings.ToLower(derefString(request.NodeImageName))),
+		NodeImageName:     request.NodeImageName,
+		NodeShape:         request.NodeShape,
+		InitialNodeLabels: request.InitialNodeLabels,
+		SshPublicKey:      request.SshPublicKey,
+		QuantityPerSubnet: request.QuantityPerSubnet,
+		SubnetIds:         request.SubnetIds,
+		Nodes:             []containerengine.Node{},
+	}
+	workRequestId := f.submitWorkRequest(containerengine.WorkRequestOperationTypeNodepoolCreate, request.CompartmentId, "NODEPOOL", nodePoolId)
+	f.save()
+
+	return containerengine.CreateNodePoolResponse{OpcWorkRequestId: common.String(workRequestId)}, nil
+}
+
+// list nodepools..
+func (f *fakeBackend) ListNodePools(ctx context.Context, request containerengine.ListNodePoolsRequest) (containerengine.ListNodePoolsResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// filter..
+	nodePoolIds := []string{}
+	for nodePoolId, nodePool := range f.state.NodePools {
+		if derefString(nodePool.CompartmentId) != derefString(request.CompartmentId) {
+			continue
+		}
+		if request.ClusterId != nil && derefString(nodePool.ClusterId) != *request.ClusterId {
+			continue
+		}
+		if request.Name != nil && derefString(nodePool.Name) != *request.Name {
+			continue
+		}
+		nodePoolIds = append(nodePoolIds, nodePoolId)
+	}
+	sort.Strings(nodePoolIds)
+
+	// page..
+	start, end, nextPage := fakePage(len(nodePoolIds), request.Page, request.Limit)
+	resp := containerengine.ListNodePoolsResponse{Items: []containerengine.NodePoolSummary{}, OpcNextPage: nextPage}
+	for _, nodePoolId := range nodePoolIds[start:end] {
+		nodePool := f.state.NodePools[nodePoolId]
+		resp.Items = append(resp.Items, containerengine.NodePoolSummary{
+			Id:                nodePool.Id,
+			CompartmentId:     nodePool.CompartmentId,
+			ClusterId:         nodePool.ClusterId,
+			Name:              nodePool.Name,
+			KubernetesVersion: nodePool.KubernetesVersion,
+			NodeImageId:       nodePool.NodeImageId,
+			NodeImageName:     nodePool.NodeImageName,
+			NodeShape:         nodePool.NodeShape,
+			InitialNodeLabels: nodePool.InitialNodeLabels,
+			SshPublicKey:      nodePool.SshPublicKey,
+			QuantityPerSubnet: nodePool.QuantityPerSubnet,
+			SubnetIds:         nodePool.SubnetIds,
+		})
+	}
+
+	return resp, nil
+}
+
+// update nodepool..
+// the update is held until its work request completes..
+func (f *fakeBackend) UpdateNodePool(ctx context.Context, request containerengine.UpdateNodePoolRequest) (containerengine.UpdateNodePoolResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	nodePool, err := f.nodePool(request.NodePoolId)
+	if err != nil {
+		return containerengine.UpdateNodePoolResponse{}, err
+	}
+	if request.QuantityPerSubnet != nil && *request.QuantityPerSubnet < 0 {
+		return containerengine.UpdateNodePoolResponse{}, fmt.Errorf("fake backend: quantityPerSubnet must not be negative")
+	}
+	workRequestId := f.submitWorkRequest(containerengine.WorkRequestOperationTypeNodepoolUpdate, nodePool.CompartmentId, "NODEPOOL", *nodePool.Id)
+	f.state.PendingNodePoolUpdates[workRequestId] = request.UpdateNodePoolDetails
+	f.save()
+
+	return containerengine.UpdateNodePoolResponse{OpcWorkRequestId: common.String(workRequestId)}, nil
+}
+
+// delete nodepool..
+func (f *fakeBackend) DeleteNodePool(ctx context.Context, request containerengine.DeleteNodePoolRequest) (containerengine.DeleteNodePoolResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	nodePool, err := f.nodePool(request.NodePoolId)
+	if err != nil {
+		return containerengine.DeleteNodePoolResponse{}, err
+	}
+	for i := range nodePool.Nodes {
+		nodePool.Nodes[i].LifecycleState = containerengine.NodeLifecycleStateDeleting
+	}
+	workRequestId := f.submitWorkRequest(containerengine.WorkRequestOperationTypeNodepoolDelete, nodePool.CompartmentId, "NODEPOOL", *nodePool.Id)
+	f.save()
+
+	return containerengine.DeleteNodePoolResponse{OpcWorkRequestId: common.String(workRequestId)}, nil
+}
+
+// get nodepool..
+func (f *fakeBackend) GetNodePool(ctx context.Context, request containerengine.GetNodePoolRequest) (containerengine.GetNodePoolResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	nodePool, err := f.nodePool(request.NodePoolId)
+	if err != nil {
+		return containerengine.GetNodePoolResponse{}, err
+	}
+
+	// respond with a copy, then advance provisioning nodes for the next caller..
+	resp := containerengine.GetNodePoolResponse{NodePool: *nodePool}
+	resp.NodePool.Nodes = append([]containerengine.Node{}, nodePool.Nodes...)
+	nodes := []containerengine.Node{}
+	for _, node := range nodePool.Nodes {
+		switch {
+		case node.LifecycleState == containerengine.NodeLifecycleStateCreating:
+			node.LifecycleState = containerengine.NodeLifecycleStateActive
+			node.LifecycleDetails = common.String("")
+		case derefString(node.LifecycleDetails) == fakeNodeTerminating:
+			continue
+		}
+		nodes = append(nodes, node)
+	}
+	nodePool.Nodes = nodes
+	f.save()
+
+	return resp, nil
+}
+
+// create kubeconfig..
+func (f *fakeBackend) CreateKubeconfig(ctx context.Context, request containerengine.CreateKubeconfigRequest) (containerengine.CreateKubeconfigResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	cluster, err := f.cluster(request.ClusterId)
+	if err != nil {
+		return containerengine.CreateKubeconfigResponse{}, err
+	}
+
+	// unsigned jwt, expiring like an OKE token..
+	expiration := 2592000
+	if request.Expiration != nil {
+		expiration = *request.Expiration
+	}
+	claims, _ := json.Marshal(map[string]interface{}{"sub": *cluster.Id, "exp": time.Now().Add(time.Duration(expiration) * time.Second).Unix()})
+	token := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","typ":"JWT"}`)) + "." + base64.RawURLEncoding.EncodeToString(claims) + "."
+
+	kubeconfig := "apiVersion: v1\n" +
+		"kind: Config\n" +
+		"clusters:\n" +
+		"- name: cluster-fake\n" +
+		"  cluster:\n" +
+		"    server: https://" + *cluster.Endpoints.Kubernetes + "\n" +
+		"users:\n" +
+		"- name: user-fake\n" +
+		"  user:\n" +
+		"    token: " + token + "\n" +
+		"contexts:\n" +
+		"- name: context-fake\n" +
+		"  context:\n" +
+		"    cluster: cluster-fake\n" +
+		"    user: user-fake\n" +
+		"current-context: context-fake\n"
+
+	return containerengine.CreateKubeconfigResponse{Content: ioutil.NopCloser(strings.NewReader(kubeconfig))}, nil
+}
+
+// terminate worker node instance..
+// like OKE, the nodepool replaces the terminated node straight away..
+func (f *fakeBackend) TerminateInstance(ctx context.Context, request core.TerminateInstanceRequest) (core.TerminateInstanceResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, nodePool := range f.state.NodePools {
+		for i, node := range nodePool.Nodes {
+			if derefString(node.Id) == derefString(request.InstanceId) {
+				nodePool.Nodes = append(nodePool.Nodes[:i:i], nodePool.Nodes[i+1:]...)
+				f.reconcileNodes(nodePool)
+				f.save()
+				return core.TerminateInstanceResponse{}, nil
+			}
+		}
+	}
+
+	return core.TerminateInstanceResponse{}, fmt.Errorf("fake backend: instance %s not found", derefString(request.InstanceId))
+}
+
+// list vnic attachments for a worker node instance..
+// each node has a single primary vnic, identified by the node's sequence number..
+func (f *fakeBackend) ListVnicAttachments(ctx context.Context, request core.ListVnicAttachmentsRequest) (core.ListVnicAttachmentsResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	resp := core.ListVnicAttachmentsResponse{Items: []core.VnicAttachment{}}
+	node, ok := f.node(derefString(request.InstanceId))
+	if !ok {
+		return resp, nil
+	}
+	sequence := strings.TrimPrefix(*node.Id, "ocid1.instance.oc1.fake.")
+	resp.Items = append(resp.Items, core.VnicAttachment{
+		Id:                 common.String("ocid1.vnicattachment.oc1.fake." + sequence),
+		VnicId:             common.String("ocid1.vnic.oc1.fake." + sequence),
+		InstanceId:         node.Id,
+		CompartmentId:      request.CompartmentId,
+		AvailabilityDomain: node.AvailabilityDomain,
+		SubnetId:           node.SubnetId,
+		LifecycleState:     core.VnicAttachmentLifecycleStateAttached,
+	})
+
+	return resp, nil
+}
+
+// get vnic..
+func (f *fakeBackend) GetVnic(ctx context.Context, request core.GetVnicRequest) (core.GetVnicResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	sequence := strings.TrimPrefix(derefString(request.VnicId), "ocid1.vnic.oc1.fake.")
+	node, ok := f.node("ocid1.instance.oc1.fake." + sequence)
+	if !ok {
+		return core.GetVnicResponse{}, fmt.Errorf("fake backend: vnic %s not found", derefString(request.VnicId))
+	}
+	number, _ := strconv.Atoi(sequence)
+
+	return core.GetVnicResponse{Vnic: core.Vnic{
+		Id:                 request.VnicId,
+		AvailabilityDomain: node.AvailabilityDomain,
+		SubnetId:           node.SubnetId,
+		IsPrimary:          common.Bool(true),
+		PrivateIp:          common.String(fmt.Sprintf("10.0.%d.%d", number/254%256, number%254+1)),
+		PublicIp:           node.PublicIp,
+	}}, nil
+}
+
+// create vcn - PROVISIONING until first polled..
+func (f *fakeBackend) CreateVcn(ctx context.Context, request core.CreateVcnRequest) (core.CreateVcnResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	vcn := &core.Vcn{
+		Id:             common.String(f.nextId("vcn")),
+		CompartmentId:  request.CompartmentId,
+		CidrBlock:      request.CidrBlock,
+		DisplayName:    request.DisplayName,
+		DnsLabel:       request.DnsLabel,
+		LifecycleState: core.VcnLifecycleStateProvisioning,
+	}
+	f.state.Vcns[*vcn.Id] = vcn
+	f.save()
+
+	return core.CreateVcnResponse{Vcn: *vcn}, nil
+}
+
+// get vcn..
+// honours the retry policy in the request metadata, as GetWorkRequest does..
+func (f *fakeBackend) GetVcn(ctx context.Context, request core.GetVcnRequest) (core.GetVcnResponse, error) {
+	ociResponse, err := f.retry(ctx, request, func() (common.OCIResponse, error) { return f.getVcn(request.VcnId) })
+	resp, _ := ociResponse.(core.GetVcnResponse)
+	return resp, err
+}
+
+// poll & advance a vcn to AVAILABLE..
+func (f *fakeBackend) getVcn(vcnId *string) (core.GetVcnResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	vcn, ok := f.state.Vcns[derefString(vcnId)]
+	if !ok {
+		return core.GetVcnResponse{}, fmt.Errorf("fake backend: vcn %s not found", derefString(vcnId))
+	}
+	resp := core.GetVcnResponse{Vcn: *vcn}
+	vcn.LifecycleState = core.VcnLifecycleStateAvailable
+	f.save()
+
+	return resp, nil
+}
+
+// create internet gateway..
+func (f *fakeBackend) CreateInternetGateway(ctx context.Context, request core.CreateInternetGatewayRequest) (core.CreateInternetGatewayResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.state.Vcns[derefString(request.VcnId)]; !ok {
+		return core.CreateInternetGatewayResponse{}, fmt.Errorf("fake backend: vcn %s not found", derefString(request.VcnId))
+	}
+	internetGateway := &core.InternetGateway{
+		Id:             common.String(f.nextId("internetgateway")),
+		CompartmentId:  request.CompartmentId,
+		VcnId:          request.VcnId,
+		DisplayName:    request.DisplayName,
+		IsEnabled:      request.IsEnabled,
+		LifecycleState: core.InternetGatewayLifecycleStateAvailable,
+	}
+	f.state.InternetGateways[*internetGateway.Id] = internetGateway
+	f.save()
+
+	return core.CreateInternetGatewayResponse{InternetGateway: *internetGateway}, nil
+}
+
+// create route table..
+func (f *fakeBackend) CreateRouteTable(ctx context.Context, request core.CreateRouteTableRequest) (core.CreateRouteTableResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.state.Vcns[derefString(request.VcnId)]; !ok {
+		return core.CreateRouteTableResponse{}, fmt.Errorf("fake backend: vcn %s not found", derefString(request.VcnId))
+	}
+	routeTable := &core.RouteTable{
+		Id:             common.String(f.nextId("routetable")),
+		CompartmentId:  request.CompartmentId,
+		VcnId:          request.VcnId,
+		DisplayName:    request.DisplayName,
+		RouteRules:     request.RouteRules,
+		LifecycleState: core.RouteTableLifecycleStateAvailable,
+	}
+	f.state.RouteTables[*routeTable.Id] = routeTable
+	f.save()
+
+	return core.CreateRouteTableResponse{RouteTable: *routeTable}, nil
+}
+
+// create security list..
+func (f *fakeBackend) CreateSecurityList(ctx context.Context, request core.CreateSecurityListRequest) (core.CreateSecurityListResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.state.Vcns[derefString(request.VcnId)]; !ok {
+		return core.CreateSecurityListResponse{}, fmt.Errorf("fake backend: vcn %s not found", derefString(request.VcnId))
+	}
+	securityList := &core.SecurityList{
+		Id:                   common.String(f.nextId("securitylist")),
+		CompartmentId:        request.CompartmentId,
+		VcnId:                request.VcnId,
+		DisplayName:          request.DisplayName,
+		IngressSecurityRules: request.IngressSecurityRules,
+		EgressSecurityRules:  request.EgressSecurityRules,
+		LifecycleState:       core.SecurityListLifecycleStateAvailable,
+	}
+	f.state.SecurityLists[*securityList.Id] = securityList
+	f.save()
+
+	return core.CreateSecurityListResponse{SecurityList: *securityList}, nil
+}
+
+// create subnet - PROVISIONING until first polled..
+func (f *fakeBackend) CreateSubnet(ctx context.Context, request core.CreateSubnetRequest) (core.CreateSubnetResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	vcn, ok := f.state.Vcns[derefString(request.VcnId)]
+	if !ok {
+		return core.CreateSubnetResponse{}, fmt.Errorf("fake backend: vcn %s not found", derefString(request.VcnId))
+	}
+	if vcn.LifecycleState != core.VcnLifecycleStateAvailable {
+		return core.CreateSubnetResponse{}, fmt.Errorf("fake backend: vcn %s is %s", *vcn.Id, vcn.LifecycleState)
+	}
+	if f.failOperations["SUBNET_CREATE"] {
+		return core.CreateSubnetResponse{}, fmt.Errorf("fake backend: subnet %s failed, as OKECTL_FAKE_FAIL names SUBNET_CREATE", derefString(request.DisplayName))
+	}
+	subnet := &core.Subnet{
+		Id:                 common.String(f.nextId("subnet")),
+		CompartmentId:      request.CompartmentId,
+		VcnId:              request.VcnId,
+		AvailabilityDomain: request.AvailabilityDomain,
+		CidrBlock:          request.CidrBlock,
+		DisplayName:        request.DisplayName,
+		DnsLabel:           request.DnsLabel,
+		RouteTableId:       request.RouteTableId,
+		SecurityListIds:    request.SecurityListIds,
+		LifecycleState:     core.SubnetLifecycleStateProvisioning,
+	}
+	f.state.Subnets[*subnet.Id] = subnet
+	f.save()
+
+	return core.CreateSubnetResponse{Subnet: *subnet}, nil
+}
+
+// get subnet..
+// honours the retry policy in the request metadata, as GetWorkRequest does..
+func (f *fakeBackend) GetSubnet(ctx context.Context, request core.GetSubnetRequest) (core.GetSubnetResponse, error) {
+	ociResponse, err := f.retry(ctx, request, func() (common.OCIResponse, error) { return f.getSubnet(request.SubnetId) })
+	resp, _ := ociResponse.(core.GetSubnetResponse)
+	return resp, err
+}
+
+// poll & advance a subnet to AVAILABLE..
+func (f *fakeBackend) getSubnet(subnetId *string) (core.GetSubnetResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	subnet, ok := f.state.Subnets[derefString(subnetId)]
+	if !ok {
+		return core.GetSubnetResponse{}, fmt.Errorf("fake backend: subnet %s not found", derefString(subnetId))
+	}
+	resp := core.GetSubnetResponse{Subnet: *subnet}
+	subnet.LifecycleState = core.SubnetLifecycleStateAvailable
+	f.save()
+
+	return resp, nil
+}
+
+// list availability domains..
+func (f *fakeBackend) ListAvailabilityDomains(ctx context.Context, request identity.ListAvailabilityDomainsRequest) (identity.ListAvailabilityDomainsResponse, error) {
+	resp := identity.ListAvailabilityDomainsResponse{}
+	for _, name := range []string{"FAKE:AD-1", "FAKE:AD-2", "FAKE:AD-3"} {
+		resp.Items = append(resp.Items, identity.AvailabilityDomain{Name: common.String(name), CompartmentId: request.CompartmentId})
+	}
+
+	return resp, nil
+}
+
+// get cluster options..
+func (f *fakeBackend) GetClusterOptions(ctx context.Context, request containerengine.GetClusterOptionsRequest) (containerengine.GetClusterOptionsResponse, error) {
+	return containerengine.GetClusterOptionsResponse{
+		ClusterOptions: containerengine.ClusterOptions{KubernetesVersions: fakeKubernetesVersions},
+	}, nil
+}
+
+// get nodepool options..
+func (f *fakeBackend) GetNodePoolOptions(ctx context.Context, request containerengine.GetNodePoolOptionsRequest) (containerengine.GetNodePoolOptionsResponse, error) {
+	return containerengine.GetNodePoolOptionsResponse{
+		NodePoolOptions: containerengine.NodePoolOptions{
+			KubernetesVersions: fakeKubernetesVersions,
+			Images:             fakeNodeImages,
+			Shapes:             fakeNodeShapes,
+		},
+	}, nil
+}
+
+// get work request..
+// honours the retry policy in the request metadata the way the sdk client does, without the backoff - unless OKECTL_FAKE_DELAY is set..
+func (f *fakeBackend) GetWorkRequest(ctx context.Context, request containerengine.GetWorkRequestRequest) (containerengine.GetWorkRequestResponse, error) {
+	ociResponse, err := f.retry(ctx, request, func() (common.OCIResponse, error) { return f.getWorkRequest(request.WorkRequestId) })
+	resp, _ := ociResponse.(containerengine.GetWorkRequestResponse)
+	return resp, err
+}
+
+// run a poll under the retry policy in the request metadata, with OKECTL_FAKE_DELAY between polls..
+// without a retry policy, poll once..
+func (f *fakeBackend) retry(ctx context.Context, request common.OCIRetryableRequest, poll func() (common.OCIResponse, error)) (common.OCIResponse, error) {
+	if request.RetryPolicy() == nil {
+		return poll()
+	}
+
+	policy := *request.RetryPolicy()
+	policy.NextDuration = func(common.OCIOperationResponse) time.Duration { return f.pollDelay }
+	operation := func(ctx context.Context, r common.OCIRequest) (common.OCIResponse, error) {
+		return poll()
+	}
+
+	return common.Retry(ctx, request, operation, policy)
+}
+
+// poll & advance a work request..
+func (f *fakeBackend) getWorkRequest(workRequestId *string) (containerengine.GetWorkRequestResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	workRequest, ok := f.state.WorkRequests[derefString(workRequestId)]
+	if !ok {
+		return containerengine.GetWorkRequestResponse{}, fmt.Errorf("fake backend: work request %s not found", derefString(workRequestId))
+	}
+
+	// respond with the current status, then advance it for the next poll..
+	resp := containerengine.GetWorkRequestResponse{WorkRequest: *workRequest}
+	switch workRequest.Status {
+	case containerengine.WorkRequestStatusAccepted:
+		workRequest.Status = containerengine.WorkRequestStatusInProgress
+		workRequest.TimeStarted = &common.SDKTime{Time: time.Now()}
+		f.logWorkRequest(workRequest, "Work request started")
+	case containerengine.WorkRequestStatusInProgress:
+		if f.failOperations[string(workRequest.OperationType)] {
+			f.failWorkRequest(workRequest)
+		} else {
+			f.completeWorkRequest(workRequest)
+		}
+	}
+	f.save()
+
+	return resp, nil
+}
+
+// apply the effect of a work request & mark it succeeded..
+func (f *fakeBackend) completeWorkRequest(workRequest *containerengine.WorkRequest) {
+	resource := &workRequest.Resources[0]
+	resourceId := *resource.Identifier
+
+	switch workRequest.OperationType {
+	case containerengine.WorkRequestOperationTypeClusterCreate:
+		f.state.Clusters[resourceId].LifecycleState = containerengine.ClusterLifecycleStateActive
+		resource.ActionType = containerengine.WorkRequestResourceActionTypeCreated
+	case containerengine.WorkRequestOperationTypeClusterUpdate:
+		cluster := f.state.Clusters[resourceId]
+		update := f.state.PendingClusterUpdates[*workRequest.Id]
+		if update.Name != nil {
+			cluster.Name = update.Name
+		}
+		if update.KubernetesVersion != nil {
+			cluster.KubernetesVersion = update.KubernetesVersion
+			cluster.AvailableKubernetesUpgrades = fakeKubernetesUpgrades(*update.KubernetesVersion)
+		}
+		delete(f.state.PendingClusterUpdates, *workRequest.Id)
+		cluster.LifecycleState = containerengine.ClusterLifecycleStateActive
+		resource.ActionType = containerengine.WorkRequestResourceActionTypeUpdated
+	case containerengine.WorkRequestOperationTypeClusterDelete:
+		f.state.Clusters[resourceId].LifecycleState = containerengine.ClusterLifecycleStateDeleted
+		for nodePoolId, nodePool := range f.state.NodePools {
+			if *nodePool.ClusterId == resourceId {
+				delete(f.state.NodePools, nodePoolId)
+			}
+		}
+		resource.ActionType = containerengine.WorkRequestResourceActionTypeDeleted
+	case containerengine.WorkRequestOperationTypeNodepoolCreate:
+		f.reconcileNodes(f.state.NodePools[resourceId])
+		resource.ActionType = containerengine.WorkRequestResourceActionTypeCreated
+	case containerengine.WorkRequestOperationTypeNodepoolUpdate:
+		nodePool := f.state.NodePools[resourceId]
+		update := f.state.PendingNodePoolUpdates[*workRequest.Id]
+		if update.Name != nil {
+			nodePool.Name = update.Name
+		}
+		if update.KubernetesVersion != nil {
+			nodePool.KubernetesVersion = update.KubernetesVersion
+		}
+		if update.QuantityPerSubnet != nil {
+			nodePool.QuantityPerSubnet = update.QuantityPerSubnet
+		}
+		if update.InitialNodeLabels != nil {
+			nodePool.InitialNodeLabels = update.InitialNodeLabels
+		}
+		if update.SubnetIds != nil {
+			nodePool.SubnetIds = update.SubnetIds
+		}
+		delete(f.state.PendingNodePoolUpdates, *workRequest.Id)
+		f.reconcileNodes(nodePool)
+		resource.ActionType = containerengine.WorkRequestResourceActionTypeUpdated
+	case containerengine.WorkRequestOperationTypeNodepoolDelete:
+		delete(f.state.NodePools, resourceId)
+		resource.ActionType = containerengine.WorkRequestResourceActionTypeDeleted
+	}
+
+	workRequest.Status = containerengine.WorkRequestStatusSucceeded
+	workRequest.TimeFinished = &common.SDKTime{Time: time.Now()}
+	f.logWorkRequest(workRequest, "Work request succeeded")
+}
+
+// mark a work request failed, recording an error..
+// a failed cluster create leaves the cluster FAILED, & a failed node pool create leaves the node pool without nodes, as OKE does..
+func (f *fakeBackend) failWorkRequest(workRequest *containerengine.WorkRequest) {
+	resource := &workRequest.Resources[0]
+	if workRequest.OperationType == containerengine.WorkRequestOperationTypeClusterCreate {
+		f.state.Clusters[*resource.Identifier].LifecycleState = containerengine.ClusterLifecycleStateFailed
+	}
+	resource.ActionType = containerengine.WorkRequestResourceActionTypeFailed
+
+	f.state.WorkRequestErrors[*workRequest.Id] = append(f.state.WorkRequestErrors[*workRequest.Id], containerengine.WorkRequestError{
+		Code:      common.String("InternalError"),
+		Message:   common.String(fmt.Sprintf("%s of %s failed (OKECTL_FAKE_FAIL)", workRequest.OperationType, *resource.Identifier)),
+		Timestamp: &common.SDKTime{Time: time.Now()},
+	})
+	workRequest.Status = containerengine.WorkRequestStatusFailed
+	workRequest.TimeFinished = &common.SDKTime{Time: time.Now()}
+	f.logWorkRequest(workRequest, "Work request failed")
+}
+
+// add a log entry to a work request..
+func (f *fakeBackend) logWorkRequest(workRequest *containerengine.WorkRequest, message string) {
+	f.state.WorkRequestLogs[*workRequest.Id] = append(f.state.WorkRequestLogs[*workRequest.Id], containerengine.WorkRequestLogEntry{
+		Message:   common.String(message),
+		Timestamp: common.String(time.Now().UTC().Format(time.RFC3339)),
+	})
+}
+
+// cancel a work request that has not finished..
+// the resource is left as it was when the work request was cancelled..
+func (f *fakeBackend) DeleteWorkRequest(ctx context.Context, request containerengine.DeleteWorkRequestRequest) (containerengine.DeleteWorkRequestResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	workRequest, ok := f.state.WorkRequests[derefString(request.WorkRequestId)]
+	if !ok {
+		return containerengine.DeleteWorkRequestResponse{}, fmt.Errorf("fake backend: work request %s not found", derefString(request.WorkRequestId))
+	}
+	if workRequest.TimeFinished != nil {
+		return containerengine.DeleteWorkRequestResponse{}, fmt.Errorf("fake backend: work request %s has already finished, status %s", *workRequest.Id, workRequest.Status)
+	}
+
+	workRequest.Status = containerengine.WorkRequestStatusCanceled
+	workRequest.TimeFinished = &common.SDKTime{Time: time.Now()}
+	f.logWorkRequest(workRequest, "Work request canceled")
+	f.save()
+
+	return containerengine.DeleteWorkRequestResponse{}, nil
+}
+
+// list work request errors..
+func (f *fakeBackend) ListWorkRequestErrors(ctx context.Context, request containerengine.ListWorkRequestErrorsRequest) (containerengine.ListWorkRequestErrorsResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.state.WorkRequests[derefString(request.WorkRequestId)]; !ok {
+		return containerengine.ListWorkRequestErrorsResponse{}, fmt.Errorf("fake backend: work request %s not found", derefString(request.WorkRequestId))
+	}
+	return containerengine.ListWorkRequestErrorsResponse{Items: f.state.WorkRequestErrors[*request.WorkRequestId]}, nil
+}
+
+// list work request logs..
+func (f *fakeBackend) ListWorkRequestLogs(ctx context.Context, request containerengine.ListWorkRequestLogsRequest) (containerengine.ListWorkRequestLogsResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.state.WorkRequests[derefString(request.WorkRequestId)]; !ok {
+		return containerengine.ListWorkRequestLogsResponse{}, fmt.Errorf("fake backend: work request %s not found", derefString(request.WorkRequestId))
+	}
+	return containerengine.ListWorkRequestLogsResponse{Items: f.state.WorkRequestLogs[*request.WorkRequestId]}, nil
+}
+
+// add or remove nodes so each nodepool subnet holds quantityPerSubnet nodes..
+// new nodes start out CREATING, & removed nodes are listed as DELETING until the next poll..
+func (f *fakeBackend) reconcileNodes(nodePool *containerengine.NodePool) {
+	nodes := []containerengine.Node{}
+	for s, subnetId := range nodePool.SubnetIds {
+		subnetNodes := []containerengine.Node{}
+		removing := []containerengine.Node{}
+		for _, node := range nodePool.Nodes {
+			switch {
+			case *node.SubnetId != subnetId:
+			case node.LifecycleState == containerengine.NodeLifecycleStateDeleting:
+				removing = append(removing, node)
+			default:
+				subnetNodes = append(subnetNodes, node)
+			}
+		}
+		for len(subnetNodes) < *nodePool.QuantityPerSubnet {
+			f.state.Sequence++
+			subnetNodes = append(subnetNodes, containerengine.Node{
+				Id:                 common.String(fmt.Sprintf("ocid1.instance.oc1.fake.%06d", f.state.Sequence)),
+				Name:               common.String(fmt.Sprintf("oke-%s-%06d", *nodePool.Name, f.state.Sequence)),
+				AvailabilityDomain: common.String(fmt.Sprintf("FAKE:AD-%d", s+1)),
+				SubnetId:           common.String(subnetId),
+				NodePoolId:         nodePool.Id,
+				PublicIp:           common.String(fmt.Sprintf("192.0.2.%d", f.state.Sequence%254+1)),
+				LifecycleState:     containerengine.NodeLifecycleStateCreating,
+				LifecycleDetails:   common.String("waiting for running compute instance"),
+			})
+		}
+		nodes = append(nodes, subnetNodes[:*nodePool.QuantityPerSubnet]...)
+
+		// surplus nodes stay listed as DELETING until the next poll..
+		for _, node := range subnetNodes[*nodePool.QuantityPerSubnet:] {
+			node.LifecycleState = containerengine.NodeLifecycleStateDeleting
+			node.LifecycleDetails = common.String(fakeNodeTerminating)
+			removing = append(removing, node)
+		}
+		nodes = append(nodes, removing...)
+	}
+	nodePool.Nodes = nodes
+}
+
+// record a new work request against a single resource..
+func (f *fakeBackend) submitWorkRequest(operationType containerengine.WorkRequestOperationTypeEnum, compartmentId *string, entityType, resourceId string) string {
+	workRequestId := f.nextId("clustersworkrequest")
+	f.state.WorkRequests[workRequestId] = &containerengine.WorkRequest{
+		Id:            common.String(workRequestId),
+		OperationType: operationType,
+		Status:        containerengine.WorkRequestStatusAccepted,
+		CompartmentId: compartmentId,
+		Resources: []containerengine.WorkRequestResource{{
+			ActionType: containerengine.WorkRequestResourceActionTypeInProgress,
+			EntityType: common.String(entityType),
+			Identifier: common.String(resourceId),
+		}},
+		TimeAccepted: &common.SDKTime{Time: time.Now()},
+	}
+	f.logWorkRequest(f.state.WorkRequests[workRequestId], "Work request accepted")
+
+	return workRequestId
+}
+
+// look up a cluster that has not been deleted..
+func (f *fakeBackend) cluster(clusterId *string) (*containerengine.Cluster, error) {
+	cluster, ok := f.state.Clusters[derefString(clusterId)]
+	if !ok || cluster.LifecycleState == containerengine.ClusterLifecycleStateDeleted {
+		return nil, fakeNotFoundError(fmt.Sprintf("fake backend: cluster %s not found", derefString(clusterId)))
+	}
+
+	return cluster, nil
+}
+
+// look up a nodepool..
+func (f *fakeBackend) nodePool(nodePoolId *string) (*containerengine.NodePool, error) {
+	nodePool, ok := f.state.NodePools[derefString(nodePoolId)]
+	if !ok {
+		return nil, fakeNotFoundError(fmt.Sprintf("fake backend: nodepool %s not found", derefString(nodePoolId)))
+	}
+
+	return nodePool, nil
+}
+
+// look up a worker node by instance id..
+func (f *fakeBackend) node(instanceId string) (containerengine.Node, bool) {
+	for _, nodePool := range f.state.NodePools {
+		for _, node := range nodePool.Nodes {
+			if derefString(node.Id) == instanceId {
+				return node, true
+			}
+		}
+	}
+
+	return containerengine.Node{}, false
+}
+
+// versions a fake cluster at the given version may be upgraded to..
+func fakeKubernetesUpgrades(version string) []string {
+	upgrades := []string{}
+	for _, candidate := range fakeKubernetesVersions {
+		if compareKubeVersions(candidate, version) > 0 {
+			upgrades = append(upgrades, candidate)
+		}
+	}
+
+	return upgrades
+}
+
+// slice bounds for one page of a list response, Limit items at a time (default 10)..
+// the page token is the offset of the next page..
+func fakePage(total int, page *string, limit *int) (start, end int, nextPage *string) {
+	pageSize := 10
+	if limit != nil && *limit > 0 {
+		pageSize = *limit
+	}
+	if page != nil {
+		start, _ = strconv.Atoi(*page)
+	}
+	if start > total {
+		start = total
+	}
+	end = start + pageSize
+	if end < total {
+		nextPage = common.String(strconv.Itoa(end))
+	} else {
+		end = total
+	}
+
+	return start, end, nextPage
+}
+
+// generate a fake ocid..
+func (f *fakeBackend) nextId(resourceType string) string {
+	f.state.Sequence++
+	return fmt.Sprintf("ocid1.%s.oc1.fake.%06d", resourceType, f.state.Sequence)
+}
+
+// persist state for the next okectl run..
+func (f *fakeBackend) save() {
+	if f.statePath == "" {
+		return
+	}
+
+	content, _ := json.MarshalIndent(f.state, "", "\t")
+	err := ioutil.WriteFile(f.statePath, content, 0666)
+	if err != nil {
+		logln("OKECTL :: Error writing fake backend state:", err)
+	}
+}
+
+// dereference an optional string..
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
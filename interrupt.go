@@ -0,0 +1,189 @@
+package main
+
+// import libraries..
+import (
+	"bufio"
+	"context"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/common"
+	"github.com/oracle/oci-go-sdk/containerengine"
+)
+
+// exit code of a run interrupted by SIGINT or SIGTERM..
+const exitInterrupted = 130
+
+// interruptedWorkRequest is a work request in flight when a run was interrupted, as kept in state.json..
+type interruptedWorkRequest struct {
+	WorkRequestId string    `json:"workRequestId"`
+	Command       string    `json:"command"`
+	Interrupted   time.Time `json:"interrupted"`
+	Canceled      bool      `json:"canceled"`
+}
+
+// workRequestsInFlight are the work requests being waited on, by id..
+var workRequestsInFlight = struct {
+	sync.Mutex
+	ids map[string]bool
+}{ids: map[string]bool{}}
+
+// commandState is the state store loaded by the running command, shared with the interrupt handler..
+var commandState = struct {
+	sync.Mutex
+	state *okectlState
+}{}
+
+// share the running command's state store with the interrupt handler, so both record changes in the one store..
+func shareState(state *okectlState) {
+	commandState.Lock()
+	commandState.state = state
+	commandState.Unlock()
+}
+
+// the running command's state store, loaded where the command has not loaded it yet..
+func sharedState() *okectlState {
+	commandState.Lock()
+	state := commandState.state
+	commandState.Unlock()
+	if state == nil {
+		state = loadState(configureFileSystem(*configDir, false))
+	}
+
+	return state
+}
+
+// record a work request as in flight, until done is called..
+func trackWorkRequest(workRequestId string) (done func()) {
+	workRequestsInFlight.Lock()
+	workRequestsInFlight.ids[workRequestId] = true
+	workRequestsInFlight.Unlock()
+
+	return func() {
+		workRequestsInFlight.Lock()
+		delete(workRequestsInFlight.ids, workRequestId)
+		workRequestsInFlight.Unlock()
+	}
+}
+
+// ids of work requests in flight, sorted..
+func inFlightWorkRequestIds() []string {
+	workRequestsInFlight.Lock()
+	defer workRequestsInFlight.Unlock()
+
+	ids := []string{}
+	for id := range workRequestsInFlight.ids {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	return ids
+}
+
+// handle SIGINT & SIGTERM..
+// the context is cancelled, & the work requests in flight are printed & recorded in the command's state store. where the
+// user confirms, they are cancelled via the api, then okectl exits with status 130..
+func handleInterrupts(cancel context.CancelFunc, client okeBackend, command string) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		sig := <-signals
+		ids := inFlightWorkRequestIds()
+		cancel()
+
+		logln("")
+		logln("OKECTL :: Interrupted by", sig, "...")
+		if len(ids) == 0 {
+			logln("OKECTL :: No work requests in flight :: Exiting..")
+			os.Exit(exitInterrupted)
+		}
+
+		// record the work requests, so they can be checked or cancelled later..
+		logln("OKECTL :: Work request(s) in flight - OKE will carry on with them unless cancelled:")
+		for _, id := range ids {
+			logln(" -", id)
+		}
+		state := sharedState()
+		state.update(func() {
+			for _, id := range ids {
+				state.addInterruptedWorkRequest(interruptedWorkRequest{WorkRequestId: id, Command: command, Interrupted: time.Now().UTC().Truncate(time.Second)})
+			}
+		})
+
+		// a second signal, or no answer, leaves them running..
+		go func() {
+			<-signals
+			logln("")
+			logln("OKECTL :: Work request(s) left running :: Exiting..")
+			os.Exit(exitInterrupted)
+		}()
+		if !confirm("OKECTL :: Cancel the work request(s)? [y/N]: ") {
+			logln("OKECTL :: Work request(s) left running - cancel later with cancelOkeWorkRequest :: Exiting..")
+			os.Exit(exitInterrupted)
+		}
+
+		for _, id := range ids {
+			err := cancelWorkRequest(context.Background(), client, id)
+			if err != nil {
+				logln("OKECTL :: Cancel Work Request", id, "::", err)
+				continue
+			}
+			logln("OKECTL :: Cancel Work Request", id, ":: Submitted ...")
+			state.update(func() { state.markWorkRequestCanceled(id) })
+		}
+		os.Exit(exitInterrupted)
+	}()
+}
+
+// block where the context was cancelled by an interrupt, leaving the signal handler to exit..
+// keeps failures caused by the cancellation from racing the handler..
+func waitIfInterrupted(ctx context.Context) {
+	if ctx.Err() != nil {
+		select {}
+	}
+}
+
+// ask a yes/no question on stdin, defaulting to no..
+func confirm(question string) bool {
+	logf("%s", question)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		logln("")
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+
+	return answer == "y" || answer == "yes"
+}
+
+// cancel a work request..
+func cancelWorkRequest(ctx context.Context, client okeBackend, workRequestId string) error {
+	_, err := client.DeleteWorkRequest(ctx, containerengine.DeleteWorkRequestRequest{WorkRequestId: common.String(workRequestId)})
+	return err
+}
+
+// record a work request left in flight by an interrupted run, replacing any earlier record of it..
+func (s *okectlState) addInterruptedWorkRequest(w interruptedWorkRequest) {
+	for i := range s.InterruptedWorkRequests {
+		if s.InterruptedWorkRequests[i].WorkRequestId == w.WorkRequestId {
+			s.InterruptedWorkRequests[i] = w
+			return
+		}
+	}
+	s.InterruptedWorkRequests = append(s.InterruptedWorkRequests, w)
+}
+
+// record an interrupted work request as cancelled..
+func (s *okectlState) markWorkRequestCanceled(workRequestId string) {
+	for i := range s.InterruptedWorkRequests {
+		if s.InterruptedWorkRequests[i].WorkRequestId == workRequestId {
+			s.InterruptedWorkRequests[i].Canceled = true
+		}
+	}
+}
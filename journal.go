@@ -0,0 +1,194 @@
+package main
+
+// import libraries..
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/common"
+	"github.com/oracle/oci-go-sdk/containerengine"
+)
+
+// createOkeCluster phases, in order, as recorded in the checkpoint journal..
+const (
+	phaseClusterSubmitted  = "clusterSubmitted"
+	phaseClusterCreated    = "clusterCreated"
+	phaseNodePoolSubmitted = "nodePoolSubmitted"
+	phaseNodePoolCreated   = "nodePoolCreated"
+	phaseNodesActive       = "nodesActive"
+)
+
+// createJournal is the checkpoint journal of a createOkeCluster run, kept in <configDir>/journal/<cluster name>.json..
+// it is written after each phase, so an interrupted run can be resumed with --resume=true, & removed once the run completes..
+type createJournal struct {
+	ClusterName          string            `json:"clusterName"`
+	Phase                string            `json:"phase"`
+	ClusterWorkRequestId string            `json:"clusterWorkRequestId,omitempty"`
+	ClusterId            string            `json:"clusterId,omitempty"`
+	ClusterAdopted       bool              `json:"clusterAdopted,omitempty"`
+	NodePools            []journalNodePool `json:"nodePools"`
+	Updated              time.Time         `json:"updated"`
+	path                 string
+}
+
+// journalNodePool records the work request & id of a node pool..
+// adopted resources existed before the run, found by name with --ifNotExists=true, so are never rolled back..
+type journalNodePool struct {
+	Name          string `json:"name"`
+	WorkRequestId string `json:"workRequestId,omitempty"`
+	NodePoolId    string `json:"nodePoolId,omitempty"`
+	Adopted       bool   `json:"adopted,omitempty"`
+}
+
+// path of the journal for a cluster name..
+func journalPath(configDirPath, clusterName string) string {
+	return filepath.Join(configDirPath, "journal", strings.NewReplacer("/", "_", "\\", "_").Replace(clusterName)+".json")
+}
+
+// journal for a cluster name - with resume, as left by an earlier run, otherwise empty..
+// an earlier run's journal that is not resumed is replaced..
+func loadJournal(configDirPath, clusterName string, resume bool) *createJournal {
+	journal := &createJournal{ClusterName: clusterName, NodePools: []journalNodePool{}, path: journalPath(configDirPath, clusterName)}
+
+	content, err := ioutil.ReadFile(journal.path)
+	switch {
+	case os.IsNotExist(err):
+		if resume {
+			logln("OKECTL :: Resume :: No journal for cluster", clusterName, ":: Starting from the beginning..")
+		}
+		return journal
+	case err != nil:
+		logln("OKECTL :: Error reading journal:", err, ":: Exiting..")
+		os.Exit(3)
+	}
+	if !resume {
+		logln("OKECTL :: Replacing the journal of an earlier run for cluster", clusterName, "- use --resume=true to resume it..")
+		return journal
+	}
+
+	err = json.Unmarshal(content, journal)
+	if err != nil {
+		logln("OKECTL :: Error reading journal", journal.path+":", err, ":: Exiting..")
+		os.Exit(3)
+	}
+	logln("OKECTL :: Resume :: Cluster", clusterName, "last completed phase:", journal.Phase)
+
+	return journal
+}
+
+// record a completed phase..
+func (j *createJournal) checkpoint(phase string) {
+	j.Phase = phase
+	j.Updated = time.Now().UTC().Truncate(time.Second)
+
+	content, _ := json.MarshalIndent(j, "", "\t")
+	err := writeFileAtomic(j.path, content)
+	if err != nil {
+		logln("OKECTL :: Error Writing journal File:", err)
+	}
+}
+
+// forget a work request, so a resumed run resubmits its phase rather than re-attaching..
+func (j *createJournal) forgetWorkRequest(workRequestId string) {
+	if j.ClusterWorkRequestId == workRequestId {
+		j.ClusterWorkRequestId = ""
+	}
+	for i := range j.NodePools {
+		if j.NodePools[i].WorkRequestId == workRequestId {
+			j.NodePools[i].WorkRequestId = ""
+		}
+	}
+	j.checkpoint(j.Phase)
+}
+
+// whether a journal work request can be re-attached to - i.e. it has not finished FAILED or CANCELED..
+// a failed one is resubmitted only once the cluster or node pool it left behind is deleted, as resubmitting would create a second of the same name.
+// while that resource remains, exits with its id..
+func (j *createJournal) resumable(ctx context.Context, client okeBackend, workRequestId string) bool {
+	resp, err := client.GetWorkRequest(ctx, containerengine.GetWorkRequestRequest{WorkRequestId: common.String(workRequestId)})
+	if err != nil {
+		return true
+	}
+	switch resp.Status {
+	case containerengine.WorkRequestStatusFailed, containerengine.WorkRequestStatusCanceled:
+	default:
+		return true
+	}
+
+	for _, resource := range resp.Resources {
+		entityType, id := strings.ToUpper(derefString(resource.EntityType)), derefString(resource.Identifier)
+		if id == "" {
+			continue
+		}
+		exists, err := resourceExists(ctx, client, entityType, id)
+		if err != nil {
+			logln("OKECTL :: Resume :: Error checking", entityType, id+":", err, ":: Exiting..")
+			os.Exit(3)
+		}
+		if !exists {
+			continue
+		}
+		logln("OKECTL :: Resume :: Work request", workRequestId, "is", resp.Status, "& left", entityType, id, "in place ..")
+		switch entityType {
+		case "CLUSTER":
+			logln("OKECTL :: Resume :: Delete it with deleteOkeCluster --clusterId=" + id + ", then resume :: Exiting..")
+		case "NODEPOOL":
+			logln("OKECTL :: Resume :: Delete it with deleteOkeNodePool --nodePoolId=" + id + ", then resume :: Exiting..")
+		}
+		os.Exit(exitWorkRequestFailed)
+	}
+
+	logln("OKECTL :: Resume :: Work request", workRequestId, "is", resp.Status, "& its resources are deleted :: Resubmitting..")
+	j.forgetWorkRequest(workRequestId)
+	return false
+}
+
+// whether the cluster or node pool of a failed work request is still in place..
+func resourceExists(ctx context.Context, client okeBackend, entityType, id string) (bool, error) {
+	var err error
+	switch entityType {
+	case "CLUSTER":
+		var resp containerengine.GetClusterResponse
+		resp, err = client.GetCluster(ctx, containerengine.GetClusterRequest{ClusterId: common.String(id)})
+		if err == nil {
+			return resp.LifecycleState != containerengine.ClusterLifecycleStateDeleted, nil
+		}
+	case "NODEPOOL":
+		_, err = client.GetNodePool(ctx, containerengine.GetNodePoolRequest{NodePoolId: common.String(id)})
+		if err == nil {
+			return true, nil
+		}
+	default:
+		return false, nil
+	}
+	if isNotFound(err) {
+		return false, nil
+	}
+
+	return false, err
+}
+
+// journal entry of a node pool, added if missing..
+func (j *createJournal) nodePool(name string) *journalNodePool {
+	for i := range j.NodePools {
+		if j.NodePools[i].Name == name {
+			return &j.NodePools[i]
+		}
+	}
+	j.NodePools = append(j.NodePools, journalNodePool{Name: name})
+
+	return &j.NodePools[len(j.NodePools)-1]
+}
+
+// remove the journal of a completed, or rolled back, run..
+func (j *createJournal) remove() {
+	err := os.Remove(j.path)
+	if err != nil && !os.IsNotExist(err) {
+		logln("OKECTL :: Error Removing journal File:", err)
+	}
+}
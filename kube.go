@@ -0,0 +1,368 @@
+package main
+
+// import libraries..
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/containerengine"
+	"gopkg.in/yaml.v2"
+)
+
+// kubeNodeClient is the subset of the kubernetes api used to cordon, drain & watch worker nodes..
+type kubeNodeClient interface {
+	findNode(node containerengine.Node) (string, error)
+	cordonNode(name string) error
+	uncordonNode(name string) error
+	drainNode(ctx context.Context, name string, timeout time.Duration) error
+	nodeReady(name string) (bool, error)
+}
+
+// kubeconfigFile holds the parts of a kubeconfig file okectl uses to reach the cluster..
+type kubeconfigFile struct {
+	CurrentContext string `yaml:"current-context"`
+	Clusters       []struct {
+		Name    string `yaml:"name"`
+		Cluster struct {
+			Server                   string `yaml:"server"`
+			CertificateAuthorityData string `yaml:"certificate-authority-data"`
+			InsecureSkipTLSVerify    bool   `yaml:"insecure-skip-tls-verify"`
+		} `yaml:"cluster"`
+	} `yaml:"clusters"`
+	Users []struct {
+		Name string `yaml:"name"`
+		User struct {
+			Token                 string `yaml:"token"`
+			ClientCertificateData string `yaml:"client-certificate-data"`
+			ClientKeyData         string `yaml:"client-key-data"`
+		} `yaml:"user"`
+	} `yaml:"users"`
+	Contexts []struct {
+		Name    string `yaml:"name"`
+		Context struct {
+			Cluster string `yaml:"cluster"`
+			User    string `yaml:"user"`
+		} `yaml:"context"`
+	} `yaml:"contexts"`
+}
+
+// kubeRestClient talks to the kubernetes api server described by a kubeconfig file..
+// where tokenSource is set, each request takes its token from it rather than the kubeconfig, so long runs outlive the kubeconfig token..
+type kubeRestClient struct {
+	server      string
+	token       string
+	tokenSource func() (string, error)
+	client      *http.Client
+}
+
+// create kubernetes client from the current context of a kubeconfig file..
+func newKubeRestClient(kubeconfigPath string) (*kubeRestClient, error) {
+	content, err := ioutil.ReadFile(kubeconfigPath)
+	if err != nil {
+		return nil, err
+	}
+	kubeconfig := kubeconfigFile{}
+	err = yaml.Unmarshal(content, &kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+
+	// resolve current context..
+	clusterName, userName := "", ""
+	for _, context := range kubeconfig.Contexts {
+		if context.Name == kubeconfig.CurrentContext || kubeconfig.CurrentContext == "" {
+			clusterName, userName = context.Context.Cluster, context.Context.User
+			break
+		}
+	}
+
+	k := &kubeRestClient{}
+	tlsConfig := &tls.Config{}
+	for _, cluster := range kubeconfig.Clusters {
+		if cluster.Name == clusterName {
+			k.server = cluster.Cluster.Server
+			tlsConfig.InsecureSkipVerify = cluster.Cluster.InsecureSkipTLSVerify
+			if cluster.Cluster.CertificateAuthorityData != "" {
+				caData, err := base64.StdEncoding.DecodeString(cluster.Cluster.CertificateAuthorityData)
+				if err != nil {
+					return nil, fmt.Errorf("kubeconfig certificate-authority-data: %v", err)
+				}
+				tlsConfig.RootCAs = x509.NewCertPool()
+				tlsConfig.RootCAs.AppendCertsFromPEM(caData)
+			}
+		}
+	}
+	for _, user := range kubeconfig.Users {
+		if user.Name == userName {
+			k.token = user.User.Token
+			if user.User.ClientCertificateData != "" {
+				certData, err := base64.StdEncoding.DecodeString(user.User.ClientCertificateData)
+				if err != nil {
+					return nil, fmt.Errorf("kubeconfig client-certificate-data: %v", err)
+				}
+				keyData, err := base64.StdEncoding.DecodeString(user.User.ClientKeyData)
+				if err != nil {
+					return nil, fmt.Errorf("kubeconfig client-key-data: %v", err)
+				}
+				cert, err := tls.X509KeyPair(certData, keyData)
+				if err != nil {
+					return nil, err
+				}
+				tlsConfig.Certificates = []tls.Certificate{cert}
+			}
+		}
+	}
+	if k.server == "" {
+		return nil, fmt.Errorf("kubeconfig %s has no cluster server for context %q", kubeconfigPath, kubeconfig.CurrentContext)
+	}
+
+	k.client = &http.Client{
+		Timeout:   30 * time.Second,
+		Transport: &http.Transport{TLSClientConfig: tlsConfig},
+	}
+
+	return k, nil
+}
+
+// issue kubernetes api request..
+func (k *kubeRestClient) do(method, path, contentType string, body interface{}, result interface{}) (int, error) {
+	var reqBody *bytes.Reader
+	if body != nil {
+		content, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		reqBody = bytes.NewReader(content)
+	} else {
+		reqBody = bytes.NewReader(nil)
+	}
+
+	req, err := http.NewRequest(method, k.server+path, reqBody)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	token := k.token
+	if k.tokenSource != nil {
+		token, err = k.tokenSource()
+		if err != nil {
+			return 0, fmt.Errorf("getting token: %v", err)
+		}
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, err
+	}
+	if resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, bytes.TrimSpace(content))
+	}
+	if result != nil {
+		err = json.Unmarshal(content, result)
+	}
+
+	return resp.StatusCode, err
+}
+
+// kubeNode is the part of a kubernetes node object okectl reads..
+type kubeNode struct {
+	Metadata struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Status struct {
+		Addresses []struct {
+			Address string `json:"address"`
+		} `json:"addresses"`
+		Conditions []struct {
+			Type   string `json:"type"`
+			Status string `json:"status"`
+		} `json:"conditions"`
+	} `json:"status"`
+}
+
+// kubePod is the part of a kubernetes pod object okectl reads..
+type kubePod struct {
+	Metadata struct {
+		Name            string            `json:"name"`
+		Namespace       string            `json:"namespace"`
+		Uid             string            `json:"uid"`
+		Annotations     map[string]string `json:"annotations"`
+		OwnerReferences []struct {
+			Kind string `json:"kind"`
+		} `json:"ownerReferences"`
+	} `json:"metadata"`
+}
+
+// find the kubernetes node name for an OKE worker node, matching on node name or ip address..
+func (k *kubeRestClient) findNode(node containerengine.Node) (string, error) {
+	nodeList := struct {
+		Items []kubeNode `json:"items"`
+	}{}
+	_, err := k.do("GET", "/api/v1/nodes", "", nil, &nodeList)
+	if err != nil {
+		return "", err
+	}
+
+	for _, item := range nodeList.Items {
+		if item.Metadata.Name == derefString(node.Name) || item.Metadata.Name == derefString(node.PublicIp) {
+			return item.Metadata.Name, nil
+		}
+		for _, address := range item.Status.Addresses {
+			if node.PublicIp != nil && address.Address == *node.PublicIp {
+				return item.Metadata.Name, nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("no kubernetes node found for worker node %s (%s)", derefString(node.Name), derefString(node.PublicIp))
+}
+
+// mark node unschedulable..
+func (k *kubeRestClient) cordonNode(name string) error {
+	patch := map[string]interface{}{"spec": map[string]interface{}{"unschedulable": true}}
+	_, err := k.do("PATCH", "/api/v1/nodes/"+url.PathEscape(name), "application/strategic-merge-patch+json", patch, nil)
+	return err
+}
+
+// mark node schedulable again..
+func (k *kubeRestClient) uncordonNode(name string) error {
+	patch := map[string]interface{}{"spec": map[string]interface{}{"unschedulable": false}}
+	_, err := k.do("PATCH", "/api/v1/nodes/"+url.PathEscape(name), "application/strategic-merge-patch+json", patch, nil)
+	return err
+}
+
+// evict pods from node, & wait for them to terminate..
+// daemonset & mirror pods are left in place, evictions refused by a disruption budget are retried.
+// retries & waits stop as soon as the context is cancelled..
+func (k *kubeRestClient) drainNode(ctx context.Context, name string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+
+	podList := struct {
+		Items []kubePod `json:"items"`
+	}{}
+	_, err := k.do("GET", "/api/v1/pods?fieldSelector="+url.QueryEscape("spec.nodeName="+name), "", nil, &podList)
+	if err != nil {
+		return err
+	}
+
+	// evict..
+	evicted := []kubePod{}
+	for _, pod := range podList.Items {
+		if _, mirror := pod.Metadata.Annotations["kubernetes.io/config.mirror"]; mirror {
+			continue
+		}
+		daemonSet := false
+		for _, owner := range pod.Metadata.OwnerReferences {
+			if owner.Kind == "DaemonSet" {
+				daemonSet = true
+			}
+		}
+		if daemonSet {
+			continue
+		}
+
+		eviction := map[string]interface{}{
+			"apiVersion": "policy/v1beta1",
+			"kind":       "Eviction",
+			"metadata":   map[string]string{"name": pod.Metadata.Name, "namespace": pod.Metadata.Namespace},
+		}
+		path := "/api/v1/namespaces/" + url.PathEscape(pod.Metadata.Namespace) + "/pods/" + url.PathEscape(pod.Metadata.Name) + "/eviction"
+		for {
+			status, err := k.do("POST", path, "application/json", eviction, nil)
+			if err == nil || status == http.StatusNotFound {
+				break
+			}
+			if status != http.StatusTooManyRequests || time.Now().After(deadline) {
+				return fmt.Errorf("evicting pod %s/%s: %v", pod.Metadata.Namespace, pod.Metadata.Name, err)
+			}
+			err = sleepContext(ctx, 5*time.Second)
+			if err != nil {
+				return err
+			}
+		}
+		evicted = append(evicted, pod)
+	}
+
+	// wait for evicted pods to terminate..
+	for _, pod := range evicted {
+		path := "/api/v1/namespaces/" + url.PathEscape(pod.Metadata.Namespace) + "/pods/" + url.PathEscape(pod.Metadata.Name)
+		for {
+			current := kubePod{}
+			status, err := k.do("GET", path, "", nil, &current)
+			if status == http.StatusNotFound || (err == nil && current.Metadata.Uid != pod.Metadata.Uid) {
+				break
+			}
+			if time.Now().After(deadline) {
+				return fmt.Errorf("timed out waiting for pod %s/%s to terminate", pod.Metadata.Namespace, pod.Metadata.Name)
+			}
+			err = sleepContext(ctx, 5*time.Second)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// report whether node has a Ready condition of True..
+func (k *kubeRestClient) nodeReady(name string) (bool, error) {
+	node := kubeNode{}
+	_, err := k.do("GET", "/api/v1/nodes/"+url.PathEscape(name), "", nil, &node)
+	if err != nil {
+		return false, err
+	}
+
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == "Ready" {
+			return condition.Status == "True", nil
+		}
+	}
+
+	return false, nil
+}
+
+// fakeKubeClient stands in for the kubernetes api when running against the fake backend..
+// every node is found by name, & is ready..
+type fakeKubeClient struct{}
+
+func (fakeKubeClient) findNode(node containerengine.Node) (string, error) {
+	return derefString(node.Name), nil
+}
+
+func (fakeKubeClient) cordonNode(name string) error {
+	return nil
+}
+
+func (fakeKubeClient) uncordonNode(name string) error {
+	return nil
+}
+
+func (fakeKubeClient) drainNode(ctx context.Context, name string, timeout time.Duration) error {
+	return nil
+}
+
+func (fakeKubeClient) nodeReady(name string) (bool, error) {
+	return true, nil
+}
@@ -0,0 +1,330 @@
+package main
+
+// import libraries..
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/common"
+	"github.com/oracle/oci-go-sdk/containerengine"
+	"gopkg.in/yaml.v2"
+)
+
+// default kubeconfig merged into by --merge=true..
+const defaultMergeInto = "~/.kube/config"
+
+// lifetime of kubeconfig tokens written for okectl's own use, e.g. by upgradeOkeNodePool..
+const defaultKubeconfigExpiration = 6 * time.Minute
+
+// merge a kubeconfig generated by okectl into another kubeconfig file, e.g. ~/.kube/config..
+// the cluster, user & context entries are inserted, or replaced, under contextName, & unrelated entries are left untouched.
+// the previous file is kept as <file>.okectl.bak, & returned as backupPath..
+func mergeKubeconfigFile(generatedPath, targetPath, contextName string, setCurrentContext bool) (backupPath string, err error) {
+	generated, err := readKubeconfigMap(generatedPath)
+	if err != nil {
+		return "", err
+	}
+	cluster, user, err := currentKubeconfigEntries(generated)
+	if err != nil {
+		return "", fmt.Errorf("%s: %v", generatedPath, err)
+	}
+
+	// an absent target is created..
+	target, err := readKubeconfigMap(targetPath)
+	switch {
+	case os.IsNotExist(err):
+		target = yaml.MapSlice{{Key: "apiVersion", Value: "v1"}, {Key: "kind", Value: "Config"}, {Key: "preferences", Value: yaml.MapSlice{}}}
+	case err != nil:
+		return "", fmt.Errorf("%s: %v", targetPath, err)
+	}
+
+	// insert or replace named entries..
+	for _, entry := range []struct {
+		list, field string
+		value       interface{}
+	}{
+		{"clusters", "cluster", cluster},
+		{"users", "user", user},
+		{"contexts", "context", yaml.MapSlice{{Key: "cluster", Value: contextName}, {Key: "user", Value: contextName}}},
+	} {
+		named := yaml.MapSlice{{Key: "name", Value: contextName}, {Key: entry.field, Value: entry.value}}
+		target = setMapSliceValue(target, entry.list, upsertNamed(mapSliceValue(target, entry.list), contextName, named))
+	}
+	if setCurrentContext || mapSliceValue(target, "current-context") == nil {
+		target = setMapSliceValue(target, "current-context", contextName)
+	}
+
+	return replaceKubeconfigFile(targetPath, target)
+}
+
+// atomically replace a kubeconfig file, keeping the previous file as <file>.okectl.bak..
+func replaceKubeconfigFile(path string, kubeconfig yaml.MapSlice) (backupPath string, err error) {
+	content, err := yaml.Marshal(kubeconfig)
+	if err != nil {
+		return "", err
+	}
+
+	previous, err := ioutil.ReadFile(path)
+	switch {
+	case err == nil:
+		backupPath = path + ".okectl.bak"
+		err = writeFileAtomic(backupPath, previous)
+		if err != nil {
+			return "", err
+		}
+	case !os.IsNotExist(err):
+		return "", err
+	}
+
+	return backupPath, writeFileAtomic(path, content)
+}
+
+// new credentials for a cluster - the user entry of a freshly generated kubeconfig..
+func renewKubeconfigCredentials(ctx context.Context, client okeBackend, clusterId string, expiration time.Duration) (interface{}, error) {
+	req := containerengine.CreateKubeconfigRequest{}
+	req.ClusterId = common.String(clusterId)
+	req.Expiration = common.Int(int(expiration.Seconds()))
+
+	resp, err := client.CreateKubeconfig(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Content.Close()
+	content, err := ioutil.ReadAll(resp.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	generated := yaml.MapSlice{}
+	err = yaml.Unmarshal(content, &generated)
+	if err != nil {
+		return nil, err
+	}
+	_, user, err := currentKubeconfigEntries(generated)
+	return user, err
+}
+
+// users of the contexts whose cluster is served at a kubernetes endpoint - e.g. host:6443..
+// users authenticating via an exec plugin hold no token, so are left out..
+func kubeconfigClusterUsers(kubeconfig yaml.MapSlice, endpoint string) []string {
+	clusters := map[interface{}]bool{}
+	for _, item := range listValue(mapSliceValue(kubeconfig, "clusters")) {
+		server, _ := mapValue(mapValue(item, "cluster"), "server").(string)
+		if strings.TrimSuffix(strings.TrimPrefix(server, "https://"), "/") == endpoint {
+			clusters[mapValue(item, "name")] = true
+		}
+	}
+
+	users := []string{}
+	seen := map[string]bool{}
+	for _, item := range listValue(mapSliceValue(kubeconfig, "contexts")) {
+		context := mapValue(item, "context")
+		user, _ := mapValue(context, "user").(string)
+		if !clusters[mapValue(context, "cluster")] || seen[user] {
+			continue
+		}
+		if mapValue(findNamed(mapSliceValue(kubeconfig, "users"), user, "user"), "exec") != nil {
+			continue
+		}
+		seen[user] = true
+		users = append(users, user)
+	}
+
+	return users
+}
+
+// token of a named user, if it has one..
+func kubeconfigUserToken(kubeconfig yaml.MapSlice, user string) string {
+	token, _ := mapValue(findNamed(mapSliceValue(kubeconfig, "users"), user, "user"), "token").(string)
+	return token
+}
+
+// expiry of a token, from the exp claim of a jwt..
+// returns false when the token is not a jwt, or has no expiry..
+func tokenExpiry(token string) (time.Time, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}, false
+	}
+	claims := struct {
+		Exp int64 `json:"exp"`
+	}{}
+	if json.Unmarshal(payload, &claims) != nil || claims.Exp == 0 {
+		return time.Time{}, false
+	}
+
+	return time.Unix(claims.Exp, 0).UTC(), true
+}
+
+// replace the credentials of named users, keeping their names..
+func replaceKubeconfigUsers(kubeconfig yaml.MapSlice, users []string, credentials interface{}) yaml.MapSlice {
+	replace := map[string]bool{}
+	for _, user := range users {
+		replace[user] = true
+	}
+
+	items := listValue(mapSliceValue(kubeconfig, "users"))
+	for i, item := range items {
+		name, _ := mapValue(item, "name").(string)
+		if !replace[name] {
+			continue
+		}
+		switch entry := item.(type) {
+		case yaml.MapSlice:
+			items[i] = setMapSliceValue(entry, "user", credentials)
+		case map[interface{}]interface{}:
+			entry["user"] = credentials
+		}
+	}
+
+	return setMapSliceValue(kubeconfig, "users", items)
+}
+
+// read a kubeconfig file, keeping every field..
+func readKubeconfigMap(path string) (yaml.MapSlice, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	kubeconfig := yaml.MapSlice{}
+	err = yaml.Unmarshal(content, &kubeconfig)
+	return kubeconfig, err
+}
+
+// cluster & user of a kubeconfig's current context, or of its first context when none is set..
+func currentKubeconfigEntries(kubeconfig yaml.MapSlice) (cluster, user interface{}, err error) {
+	currentContext, _ := mapSliceValue(kubeconfig, "current-context").(string)
+
+	var context interface{}
+	for _, item := range listValue(mapSliceValue(kubeconfig, "contexts")) {
+		if context == nil || mapValue(item, "name") == currentContext {
+			context = mapValue(item, "context")
+		}
+	}
+	if context == nil {
+		return nil, nil, fmt.Errorf("kubeconfig has no contexts")
+	}
+
+	cluster = findNamed(mapSliceValue(kubeconfig, "clusters"), mapValue(context, "cluster"), "cluster")
+	user = findNamed(mapSliceValue(kubeconfig, "users"), mapValue(context, "user"), "user")
+	if cluster == nil || user == nil {
+		return nil, nil, fmt.Errorf("kubeconfig context refers to a missing cluster or user")
+	}
+
+	return cluster, user, nil
+}
+
+// field of the entry with the given name, in a list of named entries..
+func findNamed(list interface{}, name interface{}, field string) interface{} {
+	for _, item := range listValue(list) {
+		if mapValue(item, "name") == name {
+			return mapValue(item, field)
+		}
+	}
+	return nil
+}
+
+// replace the entry with the given name in a list of named entries, or append it..
+func upsertNamed(list interface{}, name string, named yaml.MapSlice) []interface{} {
+	items := listValue(list)
+	for i, item := range items {
+		if mapValue(item, "name") == name {
+			items[i] = named
+			return items
+		}
+	}
+	return append(items, named)
+}
+
+// list value, with null treated as empty..
+func listValue(value interface{}) []interface{} {
+	items, _ := value.([]interface{})
+	return items
+}
+
+// value of a key in an ordered map..
+func mapSliceValue(m yaml.MapSlice, key string) interface{} {
+	for _, item := range m {
+		if item.Key == key {
+			return item.Value
+		}
+	}
+	return nil
+}
+
+// value of a key in a decoded yaml map - ordered at the top level of a file, unordered below..
+func mapValue(m interface{}, key string) interface{} {
+	switch m := m.(type) {
+	case yaml.MapSlice:
+		return mapSliceValue(m, key)
+	case map[interface{}]interface{}:
+		return m[key]
+	}
+	return nil
+}
+
+// set a key in an ordered map, keeping its position, or appending it..
+func setMapSliceValue(m yaml.MapSlice, key string, value interface{}) yaml.MapSlice {
+	for i, item := range m {
+		if item.Key == key {
+			m[i].Value = value
+			return m
+		}
+	}
+	return append(m, yaml.MapItem{Key: key, Value: value})
+}
+
+// write a file via a temporary file in the same directory, so readers never see a partial file..
+// kubeconfig files hold credentials, so are readable by the owner only..
+func writeFileAtomic(path string, content []byte) error {
+	dir := filepath.Dir(path)
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(dir, "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(content)
+	if err == nil {
+		err = tmp.Chmod(0600)
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// expand a leading ~ to the home directory..
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home := os.Getenv("HOME")
+	if home == "" {
+		home = os.Getenv("USERPROFILE")
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
@@ -0,0 +1,103 @@
+package main
+
+// import libraries..
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// kubeconfig with a single context, named for its cluster, user & context..
+func testKubeconfig(name, server, currentContext string) string {
+	return "apiVersion: v1\n" +
+		"kind: Config\n" +
+		"clusters:\n" +
+		"- name: " + name + "\n" +
+		"  cluster:\n" +
+		"    server: " + server + "\n" +
+		"users:\n" +
+		"- name: " + name + "\n" +
+		"  user:\n" +
+		"    token: token-" + name + "\n" +
+		"contexts:\n" +
+		"- name: " + name + "\n" +
+		"  context:\n" +
+		"    cluster: " + name + "\n" +
+		"    user: " + name + "\n" +
+		"current-context: " + currentContext + "\n"
+}
+
+// merged entries are inserted or replaced by name, & other contexts are kept..
+func TestMergeKubeconfigFile(t *testing.T) {
+	generated := testKubeconfig("context-fake", "https://fake.new:6443", "context-fake")
+	tests := []struct {
+		name               string
+		target             string
+		setCurrentContext  bool
+		wantContexts       []string
+		wantServer         string
+		wantCurrentContext string
+	}{
+		{"target absent", "", false, []string{"prod"}, "https://fake.new:6443", "prod"},
+		{"other context kept", testKubeconfig("dev", "https://dev:6443", "dev"), false, []string{"dev", "prod"}, "https://fake.new:6443", "dev"},
+		{"other context kept, set current", testKubeconfig("dev", "https://dev:6443", "dev"), true, []string{"dev", "prod"}, "https://fake.new:6443", "prod"},
+		{"context replaced", testKubeconfig("prod", "https://fake.old:6443", "prod"), false, []string{"prod"}, "https://fake.new:6443", "prod"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "okectl-test")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+			generatedPath, targetPath := filepath.Join(dir, "kubeconfig"), filepath.Join(dir, "config")
+			if err := ioutil.WriteFile(generatedPath, []byte(generated), 0600); err != nil {
+				t.Fatal(err)
+			}
+			if test.target != "" {
+				if err := ioutil.WriteFile(targetPath, []byte(test.target), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			backupPath, err := mergeKubeconfigFile(generatedPath, targetPath, "prod", test.setCurrentContext)
+			if err != nil {
+				t.Fatalf("mergeKubeconfigFile: %v", err)
+			}
+
+			// the previous target is kept..
+			if test.target == "" && backupPath != "" {
+				t.Errorf("backup %s written for an absent target", backupPath)
+			}
+			if test.target != "" {
+				backup, err := ioutil.ReadFile(backupPath)
+				if err != nil || string(backup) != test.target {
+					t.Errorf("backup %s = %q, %v, want the previous target", backupPath, backup, err)
+				}
+			}
+
+			merged, err := readKubeconfigMap(targetPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			contexts := []string{}
+			for _, item := range listValue(mapSliceValue(merged, "contexts")) {
+				contexts = append(contexts, mapValue(item, "name").(string))
+			}
+			if !reflect.DeepEqual(contexts, test.wantContexts) {
+				t.Errorf("contexts = %v, want %v", contexts, test.wantContexts)
+			}
+			if server := mapValue(findNamed(mapSliceValue(merged, "clusters"), "prod", "cluster"), "server"); server != test.wantServer {
+				t.Errorf("prod server = %v, want %s", server, test.wantServer)
+			}
+			if token := mapValue(findNamed(mapSliceValue(merged, "users"), "prod", "user"), "token"); token != "token-context-fake" {
+				t.Errorf("prod token = %v, want the generated token", token)
+			}
+			if currentContext := mapSliceValue(merged, "current-context"); currentContext != test.wantCurrentContext {
+				t.Errorf("current-context = %v, want %s", currentContext, test.wantCurrentContext)
+			}
+		})
+	}
+}
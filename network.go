@@ -0,0 +1,343 @@
+package main
+
+// import libraries..
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/oracle/oci-go-sdk/common"
+	"github.com/oracle/oci-go-sdk/core"
+	"github.com/oracle/oci-go-sdk/example/helpers"
+	"github.com/oracle/oci-go-sdk/identity"
+)
+
+// cidr blocks OKE manages worker nodes from, over ssh..
+var okeManagementCidrs = []string{"130.35.0.0/16", "134.70.0.0/17", "138.1.0.0/16", "140.91.0.0/17", "147.154.0.0/16", "192.29.0.0/16"}
+
+// okectlNetwork records the network created by createOkeNetwork, in state.json..
+// createOkeCluster uses its vcn & subnets where no --vcnId or subnet ids are given. each resource is recorded as it is
+// created, & the network is incomplete until the last is - so a failed run leaves nothing unrecorded..
+type okectlNetwork struct {
+	Incomplete                 bool            `json:"incomplete,omitempty"`
+	VcnName                    string          `json:"vcnName"`
+	CompartmentId              string          `json:"compartmentId"`
+	VcnId                      string          `json:"vcnId"`
+	InternetGatewayId          string          `json:"internetGatewayId"`
+	RouteTableId               string          `json:"routeTableId"`
+	LoadBalancerSecurityListId string          `json:"loadBalancerSecurityListId"`
+	WorkerSecurityListId       string          `json:"workerSecurityListId"`
+	LoadBalancerSubnetIds      []string        `json:"loadBalancerSubnetIds"`
+	WorkerSubnetIds            []string        `json:"workerSubnetIds"`
+	Subnets                    []networkSubnet `json:"subnets"`
+}
+
+// networkSubnet describes a subnet of the network, as output by createOkeNetwork..
+type networkSubnet struct {
+	Role               string `json:"role"`
+	Name               string `json:"name"`
+	AvailabilityDomain string `json:"availabilityDomain"`
+	CidrBlock          string `json:"cidrBlock"`
+	Id                 string `json:"id"`
+}
+
+// networkLayout is the vcn cidr block carved into the subnets OKE needs..
+// worker subnets take x.x.10.0/24 upward, & load balancer subnets x.x.20.0/24 upward, within the vcn..
+type networkLayout struct {
+	workerCidrs       []string
+	loadBalancerCidrs []string
+}
+
+// carve a vcn cidr block into 2 load balancer subnets & up to 3 worker subnets..
+func planNetworkLayout(cidrBlock string, quantityWkrSubnets int) (networkLayout, error) {
+	ip, ipNet, err := net.ParseCIDR(cidrBlock)
+	if err != nil || ip.To4() == nil {
+		return networkLayout{}, fmt.Errorf("cidrBlock %s is not an IPv4 cidr block", cidrBlock)
+	}
+	if ones, _ := ipNet.Mask.Size(); ones > 19 {
+		return networkLayout{}, fmt.Errorf("cidrBlock %s is too small - a /19 or larger is required", cidrBlock)
+	}
+	if quantityWkrSubnets < 1 || quantityWkrSubnets > 3 {
+		return networkLayout{}, fmt.Errorf("quantityWkrSubnets must be 1, 2 or 3")
+	}
+
+	base := ipNet.IP.To4()
+	subnet := func(third int) string {
+		return fmt.Sprintf("%d.%d.%d.0/24", base[0], base[1], int(base[2])+third)
+	}
+	layout := networkLayout{}
+	for i := 0; i < quantityWkrSubnets; i++ {
+		layout.workerCidrs = append(layout.workerCidrs, subnet(10+i))
+	}
+	for i := 0; i < 2; i++ {
+		layout.loadBalancerCidrs = append(layout.loadBalancerCidrs, subnet(20+i))
+	}
+
+	return layout, nil
+}
+
+// names of the availability domains of a compartment..
+func availabilityDomains(ctx context.Context, client identityBackend, compartmentId string) []string {
+	resp, err := client.ListAvailabilityDomains(ctx, identity.ListAvailabilityDomainsRequest{CompartmentId: common.String(compartmentId)})
+	helpers.FatalIfError(err)
+
+	names := []string{}
+	for _, availabilityDomain := range resp.Items {
+		names = append(names, derefString(availabilityDomain.Name))
+	}
+	if len(names) == 0 {
+		logln("OKECTL :: No availability domains found for compartment", compartmentId, ":: Exiting..")
+		os.Exit(3)
+	}
+
+	return names
+}
+
+// create vcn & wait until it is available..
+// created is called with the vcn id once it is submitted..
+func createVcn(ctx context.Context, client networkBackend, compartmentId, vcnName, cidrBlock, dnsLabel string, created func(id string)) core.Vcn {
+	req := core.CreateVcnRequest{}
+	req.CompartmentId = common.String(compartmentId)
+	req.DisplayName = common.String(vcnName)
+	req.CidrBlock = common.String(cidrBlock)
+	req.DnsLabel = common.String(dnsLabel)
+
+	logln("OKECTL :: Create VCN :: Submitted ...")
+	resp, err := client.CreateVcn(ctx, req)
+	helpers.FatalIfError(err)
+	created(derefString(resp.Id))
+
+	// retry GetVcn call until the vcn is available..
+	shouldRetryFunc := func(r common.OCIOperationResponse) bool {
+		resp, ok := r.Response.(core.GetVcnResponse)
+		return r.Error == nil && (!ok || resp.LifecycleState == core.VcnLifecycleStateProvisioning)
+	}
+	getResp, err := client.GetVcn(ctx, core.GetVcnRequest{
+		VcnId:           resp.Id,
+		RequestMetadata: helpers.GetRequestMetadataWithCustomizedRetryPolicy(shouldRetryFunc),
+	})
+	helpers.FatalIfError(err)
+	if getResp.LifecycleState != core.VcnLifecycleStateAvailable {
+		helpers.FatalIfError(fmt.Errorf("vcn %s is %s", derefString(resp.Id), getResp.LifecycleState))
+	}
+	logln("OKECTL :: Create VCN :: Complete ...", derefString(resp.Id))
+
+	return getResp.Vcn
+}
+
+// create internet gateway..
+func createInternetGateway(ctx context.Context, client networkBackend, compartmentId, vcnId, name string) core.InternetGateway {
+	req := core.CreateInternetGatewayRequest{}
+	req.CompartmentId = common.String(compartmentId)
+	req.VcnId = common.String(vcnId)
+	req.DisplayName = common.String(name)
+	req.IsEnabled = common.Bool(true)
+
+	resp, err := client.CreateInternetGateway(ctx, req)
+	helpers.FatalIfError(err)
+	logln("OKECTL :: Create Internet Gateway :: Complete ...", derefString(resp.Id))
+
+	return resp.InternetGateway
+}
+
+// create route table, routing all traffic via the internet gateway..
+func createRouteTable(ctx context.Context, client networkBackend, compartmentId, vcnId, name, internetGatewayId string) core.RouteTable {
+	req := core.CreateRouteTableRequest{}
+	req.CompartmentId = common.String(compartmentId)
+	req.VcnId = common.String(vcnId)
+	req.DisplayName = common.String(name)
+	req.RouteRules = []core.RouteRule{{
+		CidrBlock:       common.String("0.0.0.0/0"),
+		NetworkEntityId: common.String(internetGatewayId),
+	}}
+
+	resp, err := client.CreateRouteTable(ctx, req)
+	helpers.FatalIfError(err)
+	logln("OKECTL :: Create Route Table :: Complete ...", derefString(resp.Id))
+
+	return resp.RouteTable
+}
+
+// create security list..
+func createSecurityList(ctx context.Context, client networkBackend, compartmentId, vcnId, name string, ingress []core.IngressSecurityRule, egress []core.EgressSecurityRule) core.SecurityList {
+	req := core.CreateSecurityListRequest{}
+	req.CompartmentId = common.String(compartmentId)
+	req.VcnId = common.String(vcnId)
+	req.DisplayName = common.String(name)
+	req.IngressSecurityRules = ingress
+	req.EgressSecurityRules = egress
+
+	resp, err := client.CreateSecurityList(ctx, req)
+	helpers.FatalIfError(err)
+	logln("OKECTL :: Create Security List ::", name, ":: Complete ...", derefString(resp.Id))
+
+	return resp.SecurityList
+}
+
+// security rules for worker subnets, per the OKE network guide..
+// workers talk freely to each other, OKE reaches them over ssh, & they reach the internet..
+func workerSecurityRules(workerCidrs []string, sshSourceCidr string) ([]core.IngressSecurityRule, []core.EgressSecurityRule) {
+	ingress := []core.IngressSecurityRule{}
+	egress := []core.EgressSecurityRule{}
+	for _, cidr := range workerCidrs {
+		ingress = append(ingress, core.IngressSecurityRule{Protocol: common.String("all"), Source: common.String(cidr), IsStateless: common.Bool(true)})
+		egress = append(egress, core.EgressSecurityRule{Protocol: common.String("all"), Destination: common.String(cidr), IsStateless: common.Bool(true)})
+	}
+
+	// path mtu discovery..
+	ingress = append(ingress, core.IngressSecurityRule{Protocol: common.String("1"), Source: common.String("0.0.0.0/0"), IcmpOptions: &core.IcmpOptions{Type: common.Int(3), Code: common.Int(4)}})
+
+	// ssh, from OKE & the nominated source..
+	sshSources := append(append([]string{}, okeManagementCidrs...), sshSourceCidr)
+	seen := map[string]bool{}
+	for _, source := range sshSources {
+		if source == "" || seen[source] {
+			continue
+		}
+		seen[source] = true
+		ingress = append(ingress, core.IngressSecurityRule{Protocol: common.String("6"), Source: common.String(source),
+			TcpOptions: &core.TcpOptions{DestinationPortRange: &core.PortRange{Min: common.Int(22), Max: common.Int(22)}}})
+	}
+	egress = append(egress, core.EgressSecurityRule{Protocol: common.String("all"), Destination: common.String("0.0.0.0/0")})
+
+	return ingress, egress
+}
+
+// security rules for load balancer subnets, per the OKE network guide..
+// services of type LoadBalancer add their own listeners, so tcp is open in & out, statelessly..
+func loadBalancerSecurityRules() ([]core.IngressSecurityRule, []core.EgressSecurityRule) {
+	ingress := []core.IngressSecurityRule{{Protocol: common.String("6"), Source: common.String("0.0.0.0/0"), IsStateless: common.Bool(true)}}
+	egress := []core.EgressSecurityRule{{Protocol: common.String("all"), Destination: common.String("0.0.0.0/0"), IsStateless: common.Bool(true)}}
+
+	return ingress, egress
+}
+
+// create subnet & wait until it is available..
+// created is called with the subnet id once it is submitted..
+func createSubnet(ctx context.Context, client networkBackend, compartmentId, vcnId, name, dnsLabel, availabilityDomain, cidrBlock, routeTableId, securityListId string, created func(id string)) core.Subnet {
+	req := core.CreateSubnetRequest{}
+	req.CompartmentId = common.String(compartmentId)
+	req.VcnId = common.String(vcnId)
+	req.DisplayName = common.String(name)
+	req.DnsLabel = common.String(dnsLabel)
+	req.AvailabilityDomain = common.String(availabilityDomain)
+	req.CidrBlock = common.String(cidrBlock)
+	req.RouteTableId = common.String(routeTableId)
+	req.SecurityListIds = []string{securityListId}
+
+	resp, err := client.CreateSubnet(ctx, req)
+	helpers.FatalIfError(err)
+	created(derefString(resp.Id))
+
+	// retry GetSubnet call until the subnet is available..
+	shouldRetryFunc := func(r common.OCIOperationResponse) bool {
+		resp, ok := r.Response.(core.GetSubnetResponse)
+		return r.Error == nil && (!ok || resp.LifecycleState == core.SubnetLifecycleStateProvisioning)
+	}
+	getResp, err := client.GetSubnet(ctx, core.GetSubnetRequest{
+		SubnetId:        resp.Id,
+		RequestMetadata: helpers.GetRequestMetadataWithCustomizedRetryPolicy(shouldRetryFunc),
+	})
+	helpers.FatalIfError(err)
+	if getResp.LifecycleState != core.SubnetLifecycleStateAvailable {
+		helpers.FatalIfError(fmt.Errorf("subnet %s is %s", derefString(resp.Id), getResp.LifecycleState))
+	}
+	logln("OKECTL :: Create Subnet ::", name, availabilityDomain, cidrBlock, ":: Complete ...", derefString(resp.Id))
+
+	return getResp.Subnet
+}
+
+// create the vcn, internet gateway, route table, security lists & subnets an OKE cluster needs..
+// load balancer subnets go in the first 2 availability domains, & worker subnets one per availability domain..
+// record is called with the network as each resource is created, & once complete..
+func createNetwork(ctx context.Context, client networkBackend, availabilityDomains []string, compartmentId, vcnName, cidrBlock, dnsLabel, sshSourceCidr string, layout networkLayout, record func(okectlNetwork)) okectlNetwork {
+	network := okectlNetwork{Incomplete: true, VcnName: vcnName, CompartmentId: compartmentId, LoadBalancerSubnetIds: []string{}, WorkerSubnetIds: []string{}, Subnets: []networkSubnet{}}
+
+	createVcn(ctx, client, compartmentId, vcnName, cidrBlock, dnsLabel, func(id string) {
+		network.VcnId = id
+		record(network)
+	})
+	network.InternetGatewayId = derefString(createInternetGateway(ctx, client, compartmentId, network.VcnId, vcnName+"-igw").Id)
+	record(network)
+	network.RouteTableId = derefString(createRouteTable(ctx, client, compartmentId, network.VcnId, vcnName+"-routes", network.InternetGatewayId).Id)
+	record(network)
+
+	ingress, egress := workerSecurityRules(layout.workerCidrs, sshSourceCidr)
+	network.WorkerSecurityListId = derefString(createSecurityList(ctx, client, compartmentId, network.VcnId, vcnName+"-workers", ingress, egress).Id)
+	record(network)
+	ingress, egress = loadBalancerSecurityRules()
+	network.LoadBalancerSecurityListId = derefString(createSecurityList(ctx, client, compartmentId, network.VcnId, vcnName+"-loadbalancers", ingress, egress).Id)
+	record(network)
+
+	// subnets, spread across availability domains..
+	for i, cidr := range layout.workerCidrs {
+		name := fmt.Sprintf("%s-workers-%d", vcnName, i+1)
+		availabilityDomain := availabilityDomains[i%len(availabilityDomains)]
+		createSubnet(ctx, client, compartmentId, network.VcnId, name, fmt.Sprintf("workers%d", i+1), availabilityDomain, cidr, network.RouteTableId, network.WorkerSecurityListId, func(id string) {
+			network.WorkerSubnetIds = append(network.WorkerSubnetIds, id)
+			network.Subnets = append(network.Subnets, networkSubnet{Role: "worker", Name: name, AvailabilityDomain: availabilityDomain, CidrBlock: cidr, Id: id})
+			record(network)
+		})
+	}
+	for i, cidr := range layout.loadBalancerCidrs {
+		name := fmt.Sprintf("%s-loadbalancers-%d", vcnName, i+1)
+		availabilityDomain := availabilityDomains[i%len(availabilityDomains)]
+		createSubnet(ctx, client, compartmentId, network.VcnId, name, fmt.Sprintf("loadbalancers%d", i+1), availabilityDomain, cidr, network.RouteTableId, network.LoadBalancerSecurityListId, func(id string) {
+			network.LoadBalancerSubnetIds = append(network.LoadBalancerSubnetIds, id)
+			network.Subnets = append(network.Subnets, networkSubnet{Role: "loadBalancer", Name: name, AvailabilityDomain: availabilityDomain, CidrBlock: cidr, Id: id})
+			record(network)
+		})
+	}
+
+	network.Incomplete = false
+	record(network)
+
+	return network
+}
+
+// fill in the vcn, subnet & compartment ids a cluster spec lacks, from the network recorded by createOkeNetwork..
+// ids given by flag or spec file are kept..
+// an incomplete network, left by a failed createOkeNetwork, is not used..
+func applyNetworkState(spec *clusterSpec, network *okectlNetwork) {
+	if network == nil {
+		return
+	}
+	if network.Incomplete {
+		logln("OKECTL :: Network", network.VcnName, "recorded by createOkeNetwork is incomplete - not used..")
+		return
+	}
+	if spec.CompartmentId == "" {
+		spec.CompartmentId = network.CompartmentId
+	}
+	if spec.VcnId == "" {
+		spec.VcnId = network.VcnId
+	}
+	if spec.VcnId != network.VcnId {
+		return
+	}
+
+	// control plane lb subnets..
+	for len(spec.Options.ServiceLbSubnetIds) < 2 {
+		spec.Options.ServiceLbSubnetIds = append(spec.Options.ServiceLbSubnetIds, "")
+	}
+	for i := range spec.Options.ServiceLbSubnetIds {
+		if spec.Options.ServiceLbSubnetIds[i] == "" && i < len(network.LoadBalancerSubnetIds) {
+			spec.Options.ServiceLbSubnetIds[i] = network.LoadBalancerSubnetIds[i]
+		}
+	}
+
+	// worker subnets - all of them for node pools that name none..
+	for i := range spec.NodePools {
+		nodePool := &spec.NodePools[i]
+		if len(nodePool.SubnetIds) == 0 {
+			nodePool.SubnetIds = append([]string{}, network.WorkerSubnetIds...)
+			continue
+		}
+		for j := range nodePool.SubnetIds {
+			if nodePool.SubnetIds[j] == "" && j < len(network.WorkerSubnetIds) {
+				nodePool.SubnetIds[j] = network.WorkerSubnetIds[j]
+			}
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+// import libraries..
+import (
+	"reflect"
+	"testing"
+)
+
+// subnets are carved from the network address of a /19 or larger vcn..
+func TestPlanNetworkLayout(t *testing.T) {
+	tests := []struct {
+		name               string
+		cidrBlock          string
+		quantityWkrSubnets int
+		wantWorkers        []string
+		wantLoadBalancers  []string
+		wantErr            bool
+	}{
+		{"/16, 3 workers", "10.0.0.0/16", 3, []string{"10.0.10.0/24", "10.0.11.0/24", "10.0.12.0/24"}, []string{"10.0.20.0/24", "10.0.21.0/24"}, false},
+		{"/19, 1 worker", "172.16.32.0/19", 1, []string{"172.16.42.0/24"}, []string{"172.16.52.0/24", "172.16.53.0/24"}, false},
+		{"host bits set", "10.0.5.7/19", 2, []string{"10.0.10.0/24", "10.0.11.0/24"}, []string{"10.0.20.0/24", "10.0.21.0/24"}, false},
+		{"/20 too small", "10.0.0.0/20", 3, nil, nil, true},
+		{"ipv6", "fd00::/48", 3, nil, nil, true},
+		{"not a cidr", "10.0.0.0", 3, nil, nil, true},
+		{"no workers", "10.0.0.0/16", 0, nil, nil, true},
+		{"4 workers", "10.0.0.0/16", 4, nil, nil, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			layout, err := planNetworkLayout(test.cidrBlock, test.quantityWkrSubnets)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("planNetworkLayout(%s, %d) = %v, want error %t", test.cidrBlock, test.quantityWkrSubnets, err, test.wantErr)
+			}
+			if !reflect.DeepEqual(layout.workerCidrs, test.wantWorkers) || !reflect.DeepEqual(layout.loadBalancerCidrs, test.wantLoadBalancers) {
+				t.Fatalf("layout = %v & %v, want %v & %v", layout.workerCidrs, layout.loadBalancerCidrs, test.wantWorkers, test.wantLoadBalancers)
+			}
+		})
+	}
+}
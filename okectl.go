@@ -9,7 +9,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
-	"regexp"
+	"sort"
 	"strings"
 	"time"
 
@@ -24,17 +24,19 @@ import (
 var (
 	// general..
 	cleanUp                 = false        // func configureFileSystem
-	nodeLifeCycleState      = ("nil")      // func getNodeLifeCycleState
 	// app..
 	app                     = kingpin.New("okectl", "A command-line application for configuring Oracle OKE (Container Engine for Kubernetes.)")
 	configDir               = app.Flag("configDir", "Path where output files are created or referenced - e.g. kubeconfig file. Specify as absolute path.").Default(".okectl").String()
+	output                  = app.Flag("output", "Result format - table, json, yaml, jsonpath=EXPR (e.g. jsonpath={.nodes[*].publicIp}) or go-template=TEMPLATE. Banners & progress are written to stderr.").Default("table").String()
+	backend                 = app.Flag("backend", "OKE backend. If backend=oci, use the OCI tenancy. If backend=fake, simulate OKE in memory for offline testing.").Default("oci").Enum("oci", "fake")
 	// (c1) :: create cluster..
 	c1                      = app.Command("createOkeCluster", "Create new OKE Kubernetes cluster.")
-	c1VcnId                 = c1.Flag("vcnId", "OCI VCN Id where cluster will be created.").Required().String()
-	c1CompartmentId         = c1.Flag("compartmentId", "OCI Compartment-Id where cluster will be created.").Required().String()
-	c1Subnet1Id             = c1.Flag("subnet1Id", "Cluster Control Plane LB Subnet 1.").Required().String()
-	c1Subnet2Id             = c1.Flag("subnet2Id", "Cluster Control Plane LB Subnet 2.").Required().String()
-	c1Subnet3Id             = c1.Flag("subnet3Id", "Worker Node Subnet 1.").Required().String()
+	c1Spec                  = c1.Flag("spec", "Cluster spec file (yaml or json) describing the cluster, its options & node pools. Flags override spec fields - node pool flags apply to the first node pool.").String()
+	c1VcnId                 = c1.Flag("vcnId", "OCI VCN Id where cluster will be created. Required unless provided by --spec, or by createOkeNetwork.").String()
+	c1CompartmentId         = c1.Flag("compartmentId", "OCI Compartment-Id where cluster will be created. Required unless provided by --spec, or by createOkeNetwork.").String()
+	c1Subnet1Id             = c1.Flag("subnet1Id", "Cluster Control Plane LB Subnet 1. Required unless provided by --spec, or by createOkeNetwork.").String()
+	c1Subnet2Id             = c1.Flag("subnet2Id", "Cluster Control Plane LB Subnet 2. Required unless provided by --spec, or by createOkeNetwork.").String()
+	c1Subnet3Id             = c1.Flag("subnet3Id", "Worker Node Subnet 1. Required unless provided by --spec, or by createOkeNetwork.").String()
 	c1Subnet4Id             = c1.Flag("subnet4Id", "Worker Node Subnet 2.").String()
 	c1Subnet5Id             = c1.Flag("subnet5Id", "Worker Node Subnet 3.").String()
 	c1ClusterName           = c1.Flag("clusterName", "Kubernetes cluster name.").Default("dev-oke-001").String()
@@ -44,160 +46,382 @@ var (
 	c1NodeSshKey            = c1.Flag("nodeSshKey", "SSH key to provision to Worker Node(s) for remote access.").String()
 	c1QuantityWkrSubnets    = c1.Flag("quantityWkrSubnets", "Number of subnets used to host Worker Node(s).").Default("1").Int()
 	c1QuantityPerSubnet     = c1.Flag("quantityPerSubnet", "Number of Worker Nodes per subnet.").Default("1").Int()
-	c1WaitNodesActive       = c1.Flag("waitNodesActive", "If waitNodesActive=all, wait & return when all nodes in the pool are active. " +
-	                                  "If waitNodesActive=any, wait & return when any of the nodes in the pool are active. " +
-	                                  "If waitNodesActive=false, no wait & return when the node pool is active.").Default("false").String()
+	c1WaitNodesActive       = c1.Flag("waitNodesActive", waitNodesActiveHelp).Default("false").String()
+	c1Timeout               = c1.Flag("timeout", "Time allowed for Worker Nodes to become active - e.g. 30m. If timeout=0, wait indefinitely.").Default("30m").Duration()
+	c1PollInterval          = c1.Flag("pollInterval", "Initial interval between Worker Node status checks. Grows by half on each check.").Default("15s").Duration()
+	c1MaxPollInterval       = c1.Flag("maxPollInterval", "Longest interval between Worker Node status checks.").Default("1m").Duration()
+	c1Expiration            = c1.Flag("expiration", "Lifetime of the kubeconfig token - e.g. 6m, 24h.").Default("6m").Duration()
+	c1Merge                 = c1.Flag("merge", "If merge=true, merge the cluster's kubeconfig into --mergeInto, leaving unrelated entries untouched.").Default("false").Enum("true", "false")
+	c1MergeInto             = c1.Flag("mergeInto", "Kubeconfig file merged into by --merge=true. The previous file is kept as <file>.okectl.bak.").Default(defaultMergeInto).String()
+	c1KubeContext           = c1.Flag("kubeContext", "Name of the cluster, user & context entries merged into --mergeInto, replacing any of the same name. If not specified, the cluster name will be used.").String()
+	c1SetCurrentContext     = c1.Flag("setCurrentContext", "If setCurrentContext=true, make the merged context the current-context of --mergeInto.").Default("false").Enum("true", "false")
+	c1Resume                = c1.Flag("resume", "If resume=true, resume an interrupted run for the same cluster name from the last phase completed in its checkpoint journal, re-attaching to pending work requests.").Default("false").Enum("true", "false")
+	c1IfNotExists           = c1.Flag("ifNotExists", "If ifNotExists=true, adopt a cluster of the same name in the compartment, & its node pools of the same names, creating only what is missing.").Default("false").Enum("true", "false")
+	c1RollbackOnFailure     = c1.Flag("rollbackOnFailure", "If rollbackOnFailure=true, delete the cluster & node pools created during the run when a later step fails, & report what was cleaned up.").Default("false").Enum("true", "false")
+	// (p1) :: plan cluster..
+	p1                      = app.Command("planOkeCluster", "Compare a cluster spec to the live cluster & node pools, & show what would change.")
+	p1Spec                  = p1.Flag("spec", "Cluster spec file (yaml or json) describing the cluster, its options & node pools.").Required().String()
+	p1ClusterId             = p1.Flag("clusterId", "OKE Kubernetes cluster Id. If not specified, the cluster named in the spec will be looked up in the spec compartment.").String()
 	// (d1) :: delete cluster..
 	d1                      = app.Command("deleteOkeCluster", "Delete OKE Kubernetes cluster.")
-	d1ClusterId             = d1.Flag("clusterId", "OKE Kubernetes cluster Id. If not specified, clusterId contained in nodepool.json will be used.").String()
+	d1ClusterId             = d1.Flag("clusterId", "OKE Kubernetes cluster Id. If not specified, the current context will be used.").String()
+	// (g1) :: get cluster..
+	g1                      = app.Command("getOkeCluster", "Get cluster details, & create cluster.json.")
+	g1ClusterId             = g1.Flag("clusterId", "OKE Kubernetes cluster Id. If not specified, the current context will be used.").String()
+	g1TfExternalDs          = g1.Flag("tfExternalDs", "Run as a Terraform external data source, & provide json only response data for Terraform.").Default("false").String()
+	// (u1) :: upgrade cluster..
+	u1                      = app.Command("upgradeOkeCluster", "Upgrade OKE Kubernetes cluster control plane version.")
+	u1ClusterId             = u1.Flag("clusterId", "OKE Kubernetes cluster Id. If not specified, the current context will be used.").String()
+	u1KubeVersion           = u1.Flag("kubeVersion", "Kubernetes version to upgrade to. If not specified, the lowest available upgrade will be used.").String()
+	u1AllowSkipMinor        = u1.Flag("allowSkipMinor", "If allowSkipMinor=true, allow upgrades that skip one or more minor versions.").Default("false").Enum("true", "false")
+	// (l1) :: list clusters..
+	l1                      = app.Command("listOkeClusters", "List OKE Kubernetes clusters in a compartment.")
+	l1CompartmentId         = l1.Flag("compartmentId", "OCI Compartment-Id containing the clusters.").Required().String()
+	l1ClusterName           = l1.Flag("clusterName", "Only list clusters with this name.").String()
+	l1LifecycleState        = l1.Flag("lifecycleState", "Only list clusters in this lifecycle state. May be repeated.").Enums("CREATING", "ACTIVE", "FAILED", "DELETING", "DELETED", "UPDATING")
 	// (c2) :: create kubeconfig.. //update to read clusterId from file..
 	c2                      = app.Command("createOkeKubeconfig", "Create kubeconfig autentication artefact for kubectl.")
-	c2ClusterId             = c2.Flag("clusterId", "OKE Kubernetes cluster ID. If not specified, clusterId contained in nodepool.json will be used.").String()
+	c2ClusterId             = c2.Flag("clusterId", "OKE Kubernetes cluster ID. If not specified, the current context will be used.").String()
+	c2Expiration            = c2.Flag("expiration", "Lifetime of the kubeconfig token - e.g. 6m, 24h.").Default("6m").Duration()
+	c2Exec                  = c2.Flag("exec", "If exec=true, write a kubeconfig that runs okectl token to get a fresh token for kubectl, instead of embedding a token.").Default("false").Enum("true", "false")
+	c2Merge                 = c2.Flag("merge", "If merge=true, merge the cluster's kubeconfig into --mergeInto, leaving unrelated entries untouched.").Default("false").Enum("true", "false")
+	c2MergeInto             = c2.Flag("mergeInto", "Kubeconfig file merged into by --merge=true. The previous file is kept as <file>.okectl.bak.").Default(defaultMergeInto).String()
+	c2KubeContext           = c2.Flag("kubeContext", "Name of the cluster, user & context entries merged into --mergeInto, replacing any of the same name. If not specified, the cluster name will be used.").String()
+	c2SetCurrentContext     = c2.Flag("setCurrentContext", "If setCurrentContext=true, make the merged context the current-context of --mergeInto.").Default("false").Enum("true", "false")
+	// (r2) :: refresh kubeconfig..
+	r2                      = app.Command("refreshOkeKubeconfig", "Renew the token of an existing kubeconfig when it is close to expiry, rewriting only the credentials.")
+	r2ClusterId             = r2.Flag("clusterId", "OKE Kubernetes cluster ID. If not specified, the current context will be used.").String()
+	r2Kubeconfig            = r2.Flag("kubeconfig", "Kubeconfig file to refresh - e.g. ~/.kube/config. If not specified, the kubeconfig in the cluster's context directory will be used.").String()
+	r2Expiration            = r2.Flag("expiration", "Lifetime of the renewed kubeconfig token - e.g. 6m, 24h.").Default("6m").Duration()
+	r2RenewBefore           = r2.Flag("renewBefore", "Renew the token when it expires within this time - e.g. 2m. If renewBefore=0, renew only expired tokens.").Default("2m").Duration()
+	r2Force                 = r2.Flag("force", "If force=true, renew the token regardless of its expiry.").Default("false").Enum("true", "false")
+	// (t2) :: token..
+	t2                      = app.Command("token", "Print a kubectl ExecCredential holding a cluster token, cached until it nears expiry.")
+	t2ClusterId             = t2.Flag("clusterId", "OKE Kubernetes cluster ID. If not specified, the current context will be used.").String()
+	t2Expiration            = t2.Flag("expiration", "Lifetime of new tokens - e.g. 1h.").Default("1h").Duration()
+	t2RenewBefore           = t2.Flag("renewBefore", "Replace the cached token when it expires within this time - e.g. 1m.").Default("1m").Duration()
 	// (c3) :: create nodepool..
+	c3                      = app.Command("createOkeNodePool", "Create new OKE node pool in an existing cluster.")
+	c3ClusterId             = c3.Flag("clusterId", "OKE Kubernetes cluster Id. If not specified, the current context will be used.").String()
+	c3NodePoolName          = c3.Flag("nodePoolName", "Node pool name.").Required().String()
+	c3Subnet1Id             = c3.Flag("subnet1Id", "Worker Node Subnet 1.").Required().String()
+	c3Subnet2Id             = c3.Flag("subnet2Id", "Worker Node Subnet 2.").String()
+	c3Subnet3Id             = c3.Flag("subnet3Id", "Worker Node Subnet 3.").String()
+	c3KubeVersion           = c3.Flag("kubeVersion", "Kubernetes version of Worker Node(s). If not specified, the cluster version will be used.").String()
+	c3NodeImageName         = c3.Flag("nodeImageName", "OS image used for Worker Node(s).").Default("Oracle-Linux-7.4").String()
+	c3NodeShape             = c3.Flag("nodeShape", "CPU/RAM allocated to Worker Node(s).").Default("VM.Standard1.1").String()
+	c3NodeSshKey            = c3.Flag("nodeSshKey", "SSH key to provision to Worker Node(s) for remote access.").String()
+	c3QuantityWkrSubnets    = c3.Flag("quantityWkrSubnets", "Number of subnets used to host Worker Node(s).").Default("1").Int()
+	c3QuantityPerSubnet     = c3.Flag("quantityPerSubnet", "Number of Worker Nodes per subnet.").Default("1").Int()
+	c3WaitNodesActive       = c3.Flag("waitNodesActive", waitNodesActiveHelp).Default("false").String()
+	c3Timeout               = c3.Flag("timeout", "Time allowed for Worker Nodes to become active - e.g. 30m. If timeout=0, wait indefinitely.").Default("30m").Duration()
+	c3PollInterval          = c3.Flag("pollInterval", "Initial interval between Worker Node status checks. Grows by half on each check.").Default("15s").Duration()
+	c3MaxPollInterval       = c3.Flag("maxPollInterval", "Longest interval between Worker Node status checks.").Default("1m").Duration()
 	// (g3) :: get nodepool..
 	g3                      = app.Command("getOkeNodePool", "Get cluster, node pool, and node details for a specified node pool.")
-	g3NodePoolId            = g3.Flag("nodePoolId", "OKE Node Pool Id. If not specified, Id contained in nodepool.json will be used.").String()
-	g3TfExternalDs          = g3.Flag("tfExternalDs", "Run as a Terraform external data source, & provide json only response data for Terraform.").Default("false").String()
-	g3WaitNodesActive       = g3.Flag("waitNodesActive", "If waitNodesActive=all, wait & return when all nodes in the pool are active. " +
-	                                  "If waitNodesActive=any, wait & return when any of the nodes in the pool are active. " +
-	                                  "If waitNodesActive=false, no wait & return when the node pool is active.").Default("false").String()
+	g3NodePoolId            = g3.Flag("nodePoolId", "OKE Node Pool Id. If not specified, the node pool is found via --clusterId, or the node pool of the current context will be used.").String()
+	g3ClusterId             = g3.Flag("clusterId", "OKE Kubernetes cluster Id. Used to find the node pool when --nodePoolId is not specified.").String()
+	g3NodePoolName          = g3.Flag("nodePoolName", "Node pool name, used with --clusterId. May be omitted when the cluster has a single node pool.").String()
+	g3TfExternalDs          = g3.Flag("tfExternalDs", "Run as a Terraform external data source - read the query from stdin, & provide a flat json map of strings for Terraform.").Default("false").String()
+	g3WaitNodesActive       = g3.Flag("waitNodesActive", waitNodesActiveHelp).Default("false").String()
+	g3Timeout               = g3.Flag("timeout", "Time allowed for Worker Nodes to become active - e.g. 30m. If timeout=0, wait indefinitely.").Default("30m").Duration()
+	g3PollInterval          = g3.Flag("pollInterval", "Initial interval between Worker Node status checks. Grows by half on each check.").Default("15s").Duration()
+	g3MaxPollInterval       = g3.Flag("maxPollInterval", "Longest interval between Worker Node status checks.").Default("1m").Duration()
+	// (l3) :: list nodepools..
+	l3                      = app.Command("listOkeNodePools", "List node pools, & node status, for a specified cluster.")
+	l3ClusterId             = l3.Flag("clusterId", "OKE Kubernetes cluster Id. If not specified, the current context will be used.").String()
+	// (s3) :: scale nodepool..
+	s3                      = app.Command("scaleOkeNodePool", "Change the number of Worker Nodes per subnet in a node pool.")
+	s3NodePoolId            = s3.Flag("nodePoolId", "OKE Node Pool Id. If not specified, the node pool of the current context will be used.").String()
+	s3QuantityPerSubnet     = s3.Flag("quantityPerSubnet", "Number of Worker Nodes per subnet.").Required().Int()
+	s3WaitNodesActive       = s3.Flag("waitNodesActive", waitNodesActiveHelp).Default("false").String()
+	s3Timeout               = s3.Flag("timeout", "Time allowed for Worker Nodes to become active - e.g. 30m. If timeout=0, wait indefinitely.").Default("30m").Duration()
+	s3PollInterval          = s3.Flag("pollInterval", "Initial interval between Worker Node status checks. Grows by half on each check.").Default("15s").Duration()
+	s3MaxPollInterval       = s3.Flag("maxPollInterval", "Longest interval between Worker Node status checks.").Default("1m").Duration()
+	// (u3) :: upgrade nodepool..
+	u3                      = app.Command("upgradeOkeNodePool", "Upgrade node pool Kubernetes version, replacing Worker Nodes in batches with cordon & drain. Node image changes are not supported.")
+	u3NodePoolId            = u3.Flag("nodePoolId", "OKE Node Pool Id. If not specified, the node pool of the current context will be used.").String()
+	u3KubeVersion           = u3.Flag("kubeVersion", "Kubernetes version to upgrade Worker Nodes to. If not specified, the cluster version will be used. Must not be ahead of the cluster version.").String()
+	u3NodeImageName         = u3.Flag("nodeImageName", "OS image for Worker Nodes. Node images can not be changed in place by this version of the OKE API, so any image other than the current one is refused - create a new node pool instead.").String()
+	u3BatchSize             = u3.Flag("batchSize", "Number of Worker Nodes replaced at a time.").Default("1").Int()
+	u3DrainTimeout          = u3.Flag("drainTimeout", "Time allowed to drain each batch of Worker Nodes - e.g. 5m.").Default("5m").Duration()
+	u3NodeTimeout           = u3.Flag("nodeTimeout", "Time allowed for replacement Worker Nodes to become active & ready - e.g. 20m.").Default("20m").Duration()
+	u3PollInterval          = u3.Flag("pollInterval", "Initial interval between Worker Node status checks. Grows by half on each check.").Default("15s").Duration()
+	u3MaxPollInterval       = u3.Flag("maxPollInterval", "Longest interval between Worker Node status checks.").Default("1m").Duration()
 	// (d3) :: delete nodepool..
+	d3                      = app.Command("deleteOkeNodePool", "Delete OKE node pool.")
+	d3NodePoolId            = d3.Flag("nodePoolId", "OKE Node Pool Id. If not specified, the node pool of the current context will be used.").String()
+	// (l4) :: list contexts..
+	l4                      = app.Command("listContexts", "List clusters recorded in the local state store, & show the current context.")
+	// (u4) :: use context..
+	u4                      = app.Command("useContext", "Set the current context, used by commands run without --clusterId or --nodePoolId.")
+	u4Context               = u4.Flag("context", "Context name, as shown by listContexts.").Required().String()
+	// (c5) :: cancel work request..
+	c5                      = app.Command("cancelOkeWorkRequest", "Cancel an OKE work request that has not finished - e.g. one left in flight by an interrupted run.")
+	c5WorkRequestId         = c5.Flag("workRequestId", "OKE Work Request Id.").Required().String()
+	// (c6) :: create network..
+	c6                      = app.Command("createOkeNetwork", "Create the VCN, internet gateway, route table, security lists & subnets an OKE cluster needs.")
+	c6CompartmentId         = c6.Flag("compartmentId", "OCI Compartment-Id where the network will be created.").Required().String()
+	c6VcnName               = c6.Flag("vcnName", "VCN name, also used to name the network's other resources.").Default("oke-vcn").String()
+	c6CidrBlock             = c6.Flag("cidrBlock", "VCN cidr block - a /19 or larger. Worker subnets take x.x.10.0/24 upward, & load balancer subnets x.x.20.0/24 upward.").Default("10.0.0.0/16").String()
+	c6DnsLabel              = c6.Flag("dnsLabel", "VCN DNS label.").Default("oke").String()
+	c6QuantityWkrSubnets    = c6.Flag("quantityWkrSubnets", "Number of worker subnets, each in its own availability domain where there are enough.").Default("3").Int()
+	c6SshSourceCidr         = c6.Flag("sshSourceCidr", "Cidr block allowed ssh access to Worker Nodes, as well as OKE.").Default("0.0.0.0/0").String()
 )
 
 // oke crud..
 func main() {
-	ctx := context.Background()
-	c, clerr := containerengine.NewContainerEngineClientWithConfigurationProvider(common.DefaultConfigProvider())
-	helpers.FatalIfError(clerr)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
 	// command-line args & flags..
 	app.Version("0.0.3")
-	switch kingpin.MustParse(app.Parse(os.Args[1:])) {
+	command := kingpin.MustParse(app.Parse(os.Args[1:]))
+
+	// result format..
+	format, err := parseOutputFormat(*output)
+	if err != nil {
+		logln("OKECTL ::", err, ":: Exiting..")
+		os.Exit(3)
+	}
+
+	// oke backend..
+	c := newOkeBackend(*backend)
+
+	// on interrupt, report & optionally cancel work requests in flight..
+	handleInterrupts(cancel, c, command)
+
+	switch command {
 
 	// create cluster..
 	case c1.FullCommand():
 
-		fmt.Println("")
-		fmt.Println("OKECTL :: Create Cluster :: Request Parameters ...")
-		fmt.Println("-------------------------------------------------------")
-		fmt.Println("configDir:", *configDir)
-		fmt.Println("clusterName:", *c1ClusterName)
-		fmt.Println("kubeVersion:", *c1KubeVersion)
-		fmt.Println("vcnId:", *c1VcnId)
-		fmt.Println("compartmentId:", *c1CompartmentId)
-		fmt.Println("subnet1Id:", *c1Subnet1Id)
-		fmt.Println("subnet2Id:", *c1Subnet2Id)
-		fmt.Println("subnet3Id:", *c1Subnet3Id)
-		fmt.Println("subnet4Id:", *c1Subnet4Id)
-		fmt.Println("subnet5Id:", *c1Subnet5Id)
-		fmt.Println("nodeImageName:", *c1NodeImageName)
-		fmt.Println("nodeShape:", *c1NodeShape)
-		fmt.Println("nodeSshKey:", *c1NodeSshKey)
-		fmt.Println("quantityWkrSubnets:", *c1QuantityWkrSubnets)
-		fmt.Println("quantityPerSubnet:", *c1QuantityPerSubnet)
-		fmt.Println("waitNodesActive:", *c1WaitNodesActive)
-		fmt.Println("")
+		// assemble cluster spec from --spec file & flags..
+		spec := clusterSpecFromFlags()
+		if *c1Spec != "" {
+			spec = loadClusterSpec(*c1Spec)
+			applyClusterSpecFlags(&spec, flagsSetByUser())
+		}
+
+		// configure file system & state store..
+		cleanUp = false
+		configDirPath := configureFileSystem(*configDir, cleanUp)
+		state := loadState(configDirPath)
+
+		// vcn & subnet ids not provided, using the network created by createOkeNetwork..
+		applyNetworkState(&spec, state.Network)
+
+		problems := validateClusterSpec(&spec)
+		if len(problems) > 0 {
+			logln("OKECTL :: Invalid cluster spec :: Exiting..")
+			for _, problem := range problems {
+				logln(" -", problem)
+			}
+			os.Exit(3)
+		}
+
+		// check versions, images & shapes are offered before anything is created..
+		problems = preflightClusterSpec(getServiceOptions(ctx, c), spec)
+		if len(problems) > 0 {
+			logln("OKECTL :: Cluster spec requests options the service does not offer :: Exiting..")
+			for _, problem := range problems {
+				logln(" -", problem)
+			}
+			os.Exit(3)
+		}
+
+		logln("")
+		logln("OKECTL :: Create Cluster :: Request Parameters ...")
+		logln("-------------------------------------------------------")
+		logln("configDir:", *configDir)
+		logln("spec:", *c1Spec)
+		printClusterSpec(spec)
+		logln("merge:", *c1Merge)
+		if *c1Merge == "true" {
+			if *c1KubeContext == "" {
+				*c1KubeContext = spec.ClusterName
+			}
+			logln("mergeInto:", *c1MergeInto)
+			logln("kubeContext:", *c1KubeContext)
+			logln("setCurrentContext:", *c1SetCurrentContext)
+		}
+		logln("resume:", *c1Resume)
+		logln("ifNotExists:", *c1IfNotExists)
+		logln("rollbackOnFailure:", *c1RollbackOnFailure)
+		logln("")
 
 		// brief pause..
 		time.Sleep(5 * time.Second)
 
-		// configure file system..
-		cleanUp = true
-		configDirPath := configureFileSystem(*configDir, cleanUp)
+		// checkpoint journal, resumed from the last completed phase with --resume=true..
+		journal := loadJournal(configDirPath, spec.ClusterName, *c1Resume == "true")
+
+		// track resources created during the run, for rollback..
+		run := &createRun{rollback: *c1RollbackOnFailure == "true", format: format, journal: journal}
+
+		// adopt an existing cluster of the same name, & its node pools..
+		if *c1IfNotExists == "true" && journal.ClusterId == "" && journal.ClusterWorkRequestId == "" {
+			existingClusterId := findClusterId(ctx, c, spec.CompartmentId, spec.ClusterName)
+			if existingClusterId != "" {
+				logln("OKECTL :: Create Cluster :: Adopting existing cluster", spec.ClusterName, existingClusterId, "...")
+				journal.ClusterId = existingClusterId
+				journal.ClusterAdopted = true
+				journal.checkpoint(phaseClusterCreated)
+
+				existingNodePoolIds := findNodePoolIds(ctx, c, spec.CompartmentId, existingClusterId)
+				for _, nodePool := range spec.NodePools {
+					if nodePoolId, found := existingNodePoolIds[nodePool.Name]; found {
+						logln("OKECTL :: Create NodePool :: Adopting existing nodepool", nodePool.Name, nodePoolId, "...")
+						entry := journal.nodePool(nodePool.Name)
+						entry.NodePoolId = nodePoolId
+						entry.Adopted = true
+						journal.checkpoint(phaseNodePoolCreated)
+					}
+				}
+			}
+		}
 
-		// create cluster..
-		createClusterResp := createCluster(ctx, c, *c1ClusterName, *c1VcnId, *c1CompartmentId, *c1KubeVersion, *c1Subnet1Id, *c1Subnet2Id)
+		// create cluster, or re-attach to the work request of a resumed run..
+		clusterId := journal.ClusterId
+		clusterSubmitted := false
+		if clusterId == "" {
+			if journal.ClusterWorkRequestId == "" || !journal.resumable(ctx, c, journal.ClusterWorkRequestId) {
+				clusterSubmitted = true
+				createClusterResp := createCluster(ctx, c, spec.ClusterName, spec.VcnId, spec.CompartmentId, spec.KubeVersion, spec.Options.ServiceLbSubnetIds[0], spec.Options.ServiceLbSubnetIds[1],
+					*spec.Options.KubernetesDashboardEnabled, *spec.Options.TillerEnabled)
+				journal.ClusterWorkRequestId = *createClusterResp.OpcWorkRequestId
+				journal.checkpoint(phaseClusterSubmitted)
+			} else {
+				logln("OKECTL :: Create Cluster :: Resuming work request", journal.ClusterWorkRequestId, "...")
+			}
 
-		// wait for create cluster completion..
-		workReqRespCls := waitUntilWorkRequestComplete(c, createClusterResp.OpcWorkRequestId)
-		fmt.Println("OKECTL :: Create Cluster :: Complete ...")
-		clusterId := getResourceID(workReqRespCls.Resources, containerengine.WorkRequestResourceActionTypeCreated, "CLUSTER")
+			// wait for create cluster completion..
+			workReqRespCls := run.waitUntilWorkRequestComplete(ctx, c, common.String(journal.ClusterWorkRequestId), spec.ClusterName)
+			logln("OKECTL :: Create Cluster :: Complete ...")
+			clusterId = *getResourceID(workReqRespCls.Resources, containerengine.WorkRequestResourceActionTypeCreated, "CLUSTER")
+			journal.ClusterId = clusterId
+			journal.checkpoint(phaseClusterCreated)
+		} else if !journal.ClusterAdopted {
+			logln("OKECTL :: Create Cluster :: Resumed, cluster", clusterId, "already created ...")
+		}
+		if !journal.ClusterAdopted {
+			run.track("CLUSTER", clusterId, spec.ClusterName)
+		}
 
-		// create nodepool..
-		createNodePoolResp := createNodePool(ctx, c, *c1CompartmentId, *c1ClusterName, *clusterId, *c1KubeVersion, *c1NodeImageName, *c1NodeShape, *c1NodeSshKey, *c1Subnet3Id, *c1Subnet4Id, *c1Subnet5Id, *c1QuantityWkrSubnets, *c1QuantityPerSubnet)
+		// record cluster as the current context..
+		// a cluster submitted by this run replaces files left by an earlier cluster of the same name, while a resumed
+		// or adopted cluster keeps its own..
+		contextDirPath := state.contextDir(spec.ClusterName, clusterSubmitted)
+		state.setContext(spec.ClusterName, okectlContext{ClusterId: clusterId, CompartmentId: spec.CompartmentId}, true)
+		run.trackContext(state, spec.ClusterName)
+
+		// create nodepools, or re-attach to the work requests of a resumed run..
+		nodePoolIds := []string{}
+		for _, nodePool := range spec.NodePools {
+			entry := journal.nodePool(nodePool.Name)
+			if entry.NodePoolId != "" {
+				if !entry.Adopted {
+					logln("OKECTL :: Create NodePool :: Resumed, nodepool", entry.NodePoolId, "already created ...")
+					run.track("NODEPOOL", entry.NodePoolId, nodePool.Name)
+				}
+				nodePoolIds = append(nodePoolIds, entry.NodePoolId)
+				continue
+			}
 
-		// wait for create nodepool completion..
-		workReqRespNpl := waitUntilWorkRequestComplete(c, createNodePoolResp.OpcWorkRequestId)
-		fmt.Println("OKECTL :: Create NodePool :: Complete ...")
-		nodePoolId := getResourceID(workReqRespNpl.Resources, containerengine.WorkRequestResourceActionTypeCreated, "NODEPOOL")
+			if entry.WorkRequestId == "" || !journal.resumable(ctx, c, entry.WorkRequestId) {
+				subnetIds := append(append([]string{}, nodePool.SubnetIds...), "", "")
+				createNodePoolResp, err := createNodePool(ctx, c, spec.CompartmentId, nodePool.Name, clusterId, nodePool.KubeVersion, nodePool.NodeImageName, nodePool.NodeShape, nodePool.NodeSshKey,
+					subnetIds[0], subnetIds[1], subnetIds[2], len(nodePool.SubnetIds), nodePool.QuantityPerSubnet, nodeLabels(nodePool.InitialNodeLabels))
+				run.check(ctx, c, err)
+				entry.WorkRequestId = *createNodePoolResp.OpcWorkRequestId
+				journal.checkpoint(phaseNodePoolSubmitted)
+			} else {
+				logln("OKECTL :: Create NodePool :: Resuming work request", entry.WorkRequestId, "...")
+			}
+
+			// wait for create nodepool completion..
+			workReqRespNpl := run.waitUntilWorkRequestComplete(ctx, c, common.String(entry.WorkRequestId), nodePool.Name)
+			logln("OKECTL :: Create NodePool :: Complete ...")
+			nodePoolId := getResourceID(workReqRespNpl.Resources, containerengine.WorkRequestResourceActionTypeCreated, "NODEPOOL")
+			run.track("NODEPOOL", *nodePoolId, nodePool.Name)
+			entry.NodePoolId = *nodePoolId
+			journal.checkpoint(phaseNodePoolCreated)
+			nodePoolIds = append(nodePoolIds, *nodePoolId)
+		}
 
 		// wait for create node completion..
-		if *c1WaitNodesActive != "false" {
-			nodeProvisioning := true
-			// This loop continues while "nodeProvisioning" is true..
-			for nodeProvisioning {
-				getNodeLifeCycleState(ctx, c, *nodePoolId)
-				// if we are waiting for all nodes to be active..
-				if *c1WaitNodesActive == "all" {
-					boolLcs := (strings.Contains(nodeLifeCycleState, "ATING"))
-						if nodeLifeCycleState != "{}" {
-							if boolLcs != true {
-								nodeProvisioning = false
-							}
-						}
-				}
-				// if we are waiting for any nodes to be active..
-				if *c1WaitNodesActive == "any" {
-					boolLcs := (strings.Contains(nodeLifeCycleState, "ACTIVE"))
-						if nodeLifeCycleState != "{}" {
-							if boolLcs == true {
-								nodeProvisioning = false
-							}
-						}
-				}
-					time.Sleep(15 * time.Second)
+		if journal.Phase != phaseNodesActive {
+			for _, nodePoolId := range nodePoolIds {
+				run.waitUntilNodesActive(ctx, c, nodePoolId, nodeWaiter{spec.WaitNodesActive, *c1Timeout, *c1PollInterval, *c1MaxPollInterval, true})
 			}
+			journal.checkpoint(phaseNodesActive)
 		}
-		fmt.Println("OKECTL :: Create Node(s) :: Complete ...")
+		logln("OKECTL :: Create Node(s) :: Complete ...")
 
-		// get nodepool details & create nodepool.json..
-		getNodePool(ctx, c, *nodePoolId, configDirPath)
+		// get cluster & first nodepool details & create cluster.json, nodepool.json..
+		state.setNodePool(clusterId, nodePoolIds[0])
+		getClusterJson(ctx, c, clusterId, contextDirPath)
+		nodePoolResp := getNodePool(ctx, c, nodePoolIds[0], contextDirPath)
 
 		// create kubeconfig file..
-		getKubeConfig(ctx, c, *clusterId, configDirPath)
+		getKubeConfig(ctx, c, clusterId, contextDirPath, *c1Expiration)
+		state.setKubeconfigExpires(clusterId, *c1Expiration)
+		if *c1Merge == "true" {
+			mergeKubeConfig(contextDirPath, *c1MergeInto, *c1KubeContext, *c1SetCurrentContext == "true")
+		}
+
+		// done, the journal is no longer needed..
+		journal.remove()
 
 		// done, output config data..
-		fmt.Println("")
-		fmt.Println("OKECTL :: Create Cluster :: Complete ...")
-		fmt.Println("-------------------------------------------------------")
-		configFilePath := configDirPath + string(os.PathSeparator) + "nodepool.json"
-			content, err := ioutil.ReadFile(configFilePath)
-			if err != nil {
-				fmt.Println(err)
+		logln("")
+		logln("OKECTL :: Create Cluster :: Complete ...")
+		logln("-------------------------------------------------------")
+		printResult(format, nodePoolResp.NodePool, func(w io.Writer) { printNodePoolTable(w, nodePoolResp.NodePool) })
+
+	// plan cluster..
+	case p1.FullCommand():
+
+		// read & validate cluster spec..
+		spec := loadClusterSpec(*p1Spec)
+		problems := validateClusterSpec(&spec)
+		if len(problems) > 0 {
+			logln("OKECTL :: Invalid cluster spec :: Exiting..")
+			for _, problem := range problems {
+				logln(" -", problem)
 			}
-			strNodePool := string(content)
-			fmt.Println(strNodePool)
+			os.Exit(3)
+		}
 
-	// delete cluster..
-	case d1.FullCommand():
-		var clusterId(string)
+		// no --clusterId flag provided, looking up cluster by name..
+		if *p1ClusterId == "" {
+			*p1ClusterId = findClusterId(ctx, c, spec.CompartmentId, spec.ClusterName)
+		}
 
-		// no --clusterId flag provided, reading nodepool.json..
-		if *d1ClusterId == "" {
-			// configure file system..
-			cleanUp = false
-			configDirPath := configureFileSystem(*configDir, cleanUp)
+		// compare spec to live state..
+		plans := planCluster(ctx, c, spec, *p1ClusterId)
 
-			// read nodepool.json..
-			configFilePath := configDirPath + string(os.PathSeparator) + "nodepool.json"
-			content, err := ioutil.ReadFile(configFilePath)
-			if err != nil {
-				fmt.Println("OKECTL :: No --clusterId flag provided, error reading nodepool.json at specified path :: Exiting..")
-				fmt.Println(err)
-				os.Exit(3)
-			}
+		// done, output plan..
+		logln("")
+		logln("OKECTL :: Plan Cluster :: Complete ...")
+		logln("-------------------------------------------------------")
+		printResult(format, plans, func(w io.Writer) { printPlan(w, plans) })
 
-			// get clusterId from nodepool.json..
-			jsonParsed, err := gabs.ParseJSON(content)
-			clusterId = (jsonParsed.Path("clusterId").String())
-			*d1ClusterId = clusterId[1 : len(clusterId)-1]
-		}
+	// delete cluster..
+	case d1.FullCommand():
+		// configure file system & state store..
+		cleanUp = false
+		configDirPath := configureFileSystem(*configDir, cleanUp)
+		state := loadState(configDirPath)
+
+		// no --clusterId flag provided, using the current context..
+		*d1ClusterId = state.clusterId(*d1ClusterId)
 
-		fmt.Println("")
-		fmt.Println("OKECTL :: Delete Cluster :: Request Parameters ...")
-		fmt.Println("-------------------------------------------------------")
-		fmt.Println("clusterId:", *d1ClusterId)
-		fmt.Println("")
+		logln("")
+		logln("OKECTL :: Delete Cluster :: Request Parameters ...")
+		logln("-------------------------------------------------------")
+		logln("clusterId:", *d1ClusterId)
+		logln("")
 
 		// brief pause..
 		time.Sleep(5 * time.Second)
@@ -206,164 +430,816 @@ func main() {
 		deleteClusterResp := deleteCluster(ctx, c, *d1ClusterId)
 
 		// wait for delete cluster completion..
-		waitUntilWorkRequestComplete(c, deleteClusterResp.OpcWorkRequestId)
+		workReqRespCls := waitUntilWorkRequestComplete(ctx, c, deleteClusterResp.OpcWorkRequestId)
 
-		// done..
-		fmt.Println("")
-		fmt.Println("OKECTL :: Delete Cluster :: Complete ...")
+		// forget the cluster's context..
+		if contextName, ok := state.contextName(*d1ClusterId); ok {
+			state.deleteContext(contextName)
+		}
 
-	// create kubeconfig..
-	case c2.FullCommand():
-		var clusterId(string)
+		// done, output work request..
+		logln("")
+		logln("OKECTL :: Delete Cluster :: Complete ...")
+		printResult(format, workReqRespCls.WorkRequest, func(w io.Writer) { printWorkRequestTable(w, workReqRespCls.WorkRequest) })
 
-		// no --clusterId flag provided, reading nodepool.json..
-		if *c2ClusterId == "" {
-			// configure file system..
-			cleanUp = false
-			configDirPath := configureFileSystem(*configDir, cleanUp)
+	// get cluster..
+	case g1.FullCommand():
+		// configure file system & state store..
+		cleanUp = false
+		configDirPath := configureFileSystem(*configDir, cleanUp)
+		state := loadState(configDirPath)
+
+		// no --clusterId flag provided, using the current context..
+		*g1ClusterId = state.clusterId(*g1ClusterId)
+
+		if *g1TfExternalDs == "false" {
+			logln("")
+			logln("OKECTL :: Get Cluster :: Request Parameters ...")
+			logln("-------------------------------------------------------")
+			logln("clusterId:", *g1ClusterId)
+			logln("tfExternalDs:", *g1TfExternalDs)
+			logln("")
+		}
 
-			// read nodepool.json..
-			configFilePath := configDirPath + string(os.PathSeparator) + "nodepool.json"
-			content, err := ioutil.ReadFile(configFilePath)
-			if err != nil {
-				fmt.Println("OKECTL :: No --clusterId flag provided, error reading nodepool.json at specified path :: Exiting..")
-				fmt.Println(err)
-				os.Exit(3)
+		// get cluster details & create cluster.json..
+		clusterResp := getClusterJson(ctx, c, *g1ClusterId, state.clusterDir(ctx, c, *g1ClusterId))
+
+		// done, output config data..
+		// if we are running as a terraform external data source, return only json data..
+		if *g1TfExternalDs == "true" {
+			jsonObj := gabs.New()
+			jsonObj.Set(derefString(clusterResp.Id), "clusterId")
+			jsonObj.Set(derefString(clusterResp.Name), "clusterName")
+			jsonObj.Set(derefString(clusterResp.KubernetesVersion), "kubernetesVersion")
+			jsonObj.Set(derefString(clusterResp.VcnId), "vcnId")
+			jsonObj.Set(string(clusterResp.LifecycleState), "lifecycleState")
+			if clusterResp.Endpoints != nil {
+				jsonObj.Set(derefString(clusterResp.Endpoints.Kubernetes), "kubernetesEndpoint")
 			}
 
-			// get clusterId from nodepool.json..
-			jsonParsed, err := gabs.ParseJSON(content)
-			clusterId = (jsonParsed.Path("clusterId").String())
-			*c2ClusterId = clusterId[1 : len(clusterId)-1]
+			fmt.Println(jsonObj.String())
+
+		} else {
+			// not running as terraform external data source, return verbose output..
+			logln("")
+			logln("OKECTL :: Get Cluster :: Complete ...")
+			logln("-------------------------------------------------------")
+			printResult(format, clusterResp.Cluster, func(w io.Writer) { printClusterTable(w, clusterResp.Cluster) })
+		}
+
+	// upgrade cluster..
+	case u1.FullCommand():
+		// configure file system & state store..
+		cleanUp = false
+		configDirPath := configureFileSystem(*configDir, cleanUp)
+		state := loadState(configDirPath)
+
+		// no --clusterId flag provided, using the current context..
+		*u1ClusterId = state.clusterId(*u1ClusterId)
+
+		// check upgrade against the versions the cluster supports..
+		clusterResp := getCluster(ctx, c, *u1ClusterId)
+		currentVersion := derefString(clusterResp.KubernetesVersion)
+		targetVersion, err := checkClusterUpgrade(currentVersion, *u1KubeVersion, clusterResp.AvailableKubernetesUpgrades, *u1AllowSkipMinor == "true")
+		if err != nil {
+			logln("OKECTL :: Upgrade Cluster ::", err, ":: Exiting..")
+			os.Exit(3)
 		}
 
-		fmt.Println("")
-		fmt.Println("OKECTL :: Create kubeconfig :: Request Parameters ...")
-		fmt.Println("-------------------------------------------------------")
-		fmt.Println("configDir:", *configDir)
-		fmt.Println("clusterId:", *c2ClusterId)
-		fmt.Println("")
+		logln("")
+		logln("OKECTL :: Upgrade Cluster :: Request Parameters ...")
+		logln("-------------------------------------------------------")
+		logln("clusterId:", *u1ClusterId)
+		logln("currentVersion:", currentVersion)
+		logln("kubeVersion:", targetVersion)
+		logln("allowSkipMinor:", *u1AllowSkipMinor)
+		logln("")
 
 		// brief pause..
 		time.Sleep(5 * time.Second)
 
-		// configure file system..
+		// upgrade cluster..
+		upgradeClusterResp := upgradeCluster(ctx, c, *u1ClusterId, targetVersion)
+
+		// wait for upgrade cluster completion..
+		waitUntilWorkRequestComplete(ctx, c, upgradeClusterResp.OpcWorkRequestId)
+
+		// get cluster details & refresh cluster.json..
+		upgradedClusterResp := getClusterJson(ctx, c, *u1ClusterId, state.clusterDir(ctx, c, *u1ClusterId))
+
+		// flag node pools now behind the control plane..
+		outdated := outdatedNodePools(ctx, c, *clusterResp.CompartmentId, *u1ClusterId, targetVersion)
+		for _, nodePool := range outdated {
+			logln("OKECTL :: NodePool", derefString(nodePool.Name), "(" + derefString(nodePool.Id) + ") is at version",
+				derefString(nodePool.KubernetesVersion), "- behind cluster version", targetVersion)
+		}
+
+		// done, output cluster data..
+		logln("")
+		logln("OKECTL :: Upgrade Cluster :: Complete ...")
+		printResult(format, upgradedClusterResp.Cluster, func(w io.Writer) { printClusterTable(w, upgradedClusterResp.Cluster) })
+
+	// list clusters..
+	case l1.FullCommand():
+
+		// list clusters..
+		clusters := listClusters(ctx, c, *l1CompartmentId, *l1ClusterName, *l1LifecycleState)
+
+		// done, output cluster data..
+		printResult(format, clusters, func(w io.Writer) {
+			fmt.Fprintln(w, "NAME\tVERSION\tLIFECYCLE STATE\tCLUSTER ID")
+			for _, cluster := range clusters {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", derefString(cluster.Name), derefString(cluster.KubernetesVersion), cluster.LifecycleState, derefString(cluster.Id))
+			}
+		})
+
+	// create kubeconfig..
+	case c2.FullCommand():
+		// configure file system & state store..
 		cleanUp = false
 		configDirPath := configureFileSystem(*configDir, cleanUp)
+		state := loadState(configDirPath)
+
+		// no --clusterId flag provided, using the current context..
+		*c2ClusterId = state.clusterId(*c2ClusterId)
+
+		logln("")
+		logln("OKECTL :: Create kubeconfig :: Request Parameters ...")
+		logln("-------------------------------------------------------")
+		logln("configDir:", *configDir)
+		logln("clusterId:", *c2ClusterId)
+		logln("exec:", *c2Exec)
+		logln("merge:", *c2Merge)
+		contextDirPath := state.clusterDir(ctx, c, *c2ClusterId)
+		if *c2Merge == "true" {
+			if *c2KubeContext == "" {
+				*c2KubeContext, _ = state.contextName(*c2ClusterId)
+			}
+			logln("mergeInto:", *c2MergeInto)
+			logln("kubeContext:", *c2KubeContext)
+			logln("setCurrentContext:", *c2SetCurrentContext)
+		}
+		logln("")
+
+		// brief pause..
+		time.Sleep(5 * time.Second)
 
 		// create kubeconfig file..
-		getKubeConfig(ctx, c, *c2ClusterId, configDirPath)
+		getKubeConfig(ctx, c, *c2ClusterId, contextDirPath, *c2Expiration)
+		if *c2Exec == "true" {
+			// swap the token for okectl token..
+			err := useExecKubeconfigUsers(contextDirPath+string(os.PathSeparator)+"kubeconfig", *c2ClusterId, *configDir, *backend)
+			if err != nil {
+				logln("OKECTL :: Error Writing kubeconfig File:", err, ":: Exiting..")
+				os.Exit(3)
+			}
+		} else {
+			state.setKubeconfigExpires(*c2ClusterId, *c2Expiration)
+		}
+		if *c2Merge == "true" {
+			mergeKubeConfig(contextDirPath, *c2MergeInto, *c2KubeContext, *c2SetCurrentContext == "true")
+		}
 
-		// done..
-		fmt.Println("")
-		fmt.Println("OKECTL :: Create kubeconfig :: Complete ...")
+		// done, output kubeconfig location..
+		logln("")
+		logln("OKECTL :: Create kubeconfig :: Complete ...")
+		kubeconfigResult := map[string]string{"clusterId": *c2ClusterId, "kubeconfig": contextDirPath + string(os.PathSeparator) + "kubeconfig"}
+		if *c2Merge == "true" {
+			kubeconfigResult["mergedInto"] = expandHome(*c2MergeInto)
+			kubeconfigResult["kubeContext"] = *c2KubeContext
+		}
+		printResult(format, kubeconfigResult, func(w io.Writer) {
+			if *c2Merge == "true" {
+				fmt.Fprintln(w, "KUBECONFIG\tCLUSTER ID\tMERGED INTO\tKUBE CONTEXT")
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", kubeconfigResult["kubeconfig"], kubeconfigResult["clusterId"], kubeconfigResult["mergedInto"], kubeconfigResult["kubeContext"])
+				return
+			}
+			fmt.Fprintln(w, "KUBECONFIG\tCLUSTER ID")
+			fmt.Fprintf(w, "%s\t%s\n", kubeconfigResult["kubeconfig"], kubeconfigResult["clusterId"])
+		})
 
-	// get node pool..
-	case g3.FullCommand():
-		var nodePoolId(string)
+	// refresh kubeconfig..
+	case r2.FullCommand():
 
-		// configure file system..
+		// configure file system & state store..
 		cleanUp = false
 		configDirPath := configureFileSystem(*configDir, cleanUp)
+		state := loadState(configDirPath)
+
+		// no --clusterId flag provided, using the current context..
+		*r2ClusterId = state.clusterId(*r2ClusterId)
+
+		// no --kubeconfig flag provided, using the context kubeconfig..
+		contextDirPath := state.clusterDir(ctx, c, *r2ClusterId)
+		contextKubeconfig := *r2Kubeconfig == ""
+		if contextKubeconfig {
+			*r2Kubeconfig = contextDirPath + string(os.PathSeparator) + "kubeconfig"
+		}
+		kubeconfigPath := expandHome(*r2Kubeconfig)
+
+		// find the cluster's users in the kubeconfig..
+		clusterResp := getCluster(ctx, c, *r2ClusterId)
+		endpoint := ""
+		if clusterResp.Endpoints != nil {
+			endpoint = derefString(clusterResp.Endpoints.Kubernetes)
+		}
+		kubeconfig, err := readKubeconfigMap(kubeconfigPath)
+		if err != nil {
+			logln("OKECTL :: Error reading kubeconfig:", err, "- run createOkeKubeconfig :: Exiting..")
+			os.Exit(3)
+		}
+		users := kubeconfigClusterUsers(kubeconfig, endpoint)
+		if len(users) == 0 {
+			logln("OKECTL :: No token user in", kubeconfigPath, "for cluster endpoint", endpoint, "- run createOkeKubeconfig. Users of okectl token renew their own tokens :: Exiting..")
+			os.Exit(3)
+		}
 
-		// no --nodePoolId flag provided, reading nodepool.json..
-		if *g3NodePoolId == "" {
+		// earliest token expiry, from the tokens or as recorded when the context kubeconfig was written..
+		contextName, _ := state.contextName(*r2ClusterId)
+		recordedExpires := state.Contexts[contextName].KubeconfigExpires
+		var expires time.Time
+		known := true
+		for _, user := range users {
+			userExpires, ok := tokenExpiry(kubeconfigUserToken(kubeconfig, user))
+			if !ok && contextKubeconfig && recordedExpires != nil {
+				userExpires, ok = *recordedExpires, true
+			}
+			if !ok {
+				known = false
+			} else if expires.IsZero() || userExpires.Before(expires) {
+				expires = userExpires
+			}
+		}
+		renew := *r2Force == "true" || !known || time.Until(expires) <= *r2RenewBefore
 
-			// read nodepool.json..
-			configFilePath := configDirPath + string(os.PathSeparator) + "nodepool.json"
-			content, err := ioutil.ReadFile(configFilePath)
+		expiresText := "unknown"
+		if known {
+			expiresText = expires.Format(time.RFC3339)
+		}
+		logln("")
+		logln("OKECTL :: Refresh kubeconfig :: Request Parameters ...")
+		logln("-------------------------------------------------------")
+		logln("clusterId:", *r2ClusterId)
+		logln("kubeconfig:", kubeconfigPath)
+		logln("users:", strings.Join(users, ","))
+		logln("expires:", expiresText)
+		logln("renewBefore:", *r2RenewBefore)
+		logln("expiration:", *r2Expiration)
+		logln("force:", *r2Force)
+		logln("")
+
+		// renew credentials, keeping entry names..
+		if renew {
+
+			// brief pause..
+			time.Sleep(5 * time.Second)
+
+			logln("OKECTL :: Renewing kubeconfig token ...")
+			credentials, err := renewKubeconfigCredentials(ctx, c, *r2ClusterId, *r2Expiration)
+			if err != nil {
+				logln("OKECTL :: Error renewing kubeconfig token:", err, ":: Exiting..")
+				os.Exit(3)
+			}
+			backupPath, err := replaceKubeconfigFile(kubeconfigPath, replaceKubeconfigUsers(kubeconfig, users, credentials))
 			if err != nil {
-				fmt.Println("OKECTL :: No --nodePoolId flag provided, error reading nodepool.json at specified path :: Exiting..")
-				fmt.Println(err)
+				logln("OKECTL :: Error Writing kubeconfig File:", err, ":: Exiting..")
 				os.Exit(3)
 			}
+			logln("OKECTL :: Previous kubeconfig kept as", backupPath)
+			if contextKubeconfig {
+				state.setKubeconfigExpires(*r2ClusterId, *r2Expiration)
+			}
+			expiresText = time.Now().Add(*r2Expiration).UTC().Format(time.RFC3339)
+		} else {
+			logln("OKECTL :: kubeconfig token valid until", expiresText, "- not renewed")
+		}
+
+		// done, output kubeconfig state..
+		logln("")
+		logln("OKECTL :: Refresh kubeconfig :: Complete ...")
+		refreshResult := map[string]string{"clusterId": *r2ClusterId, "kubeconfig": kubeconfigPath, "renewed": fmt.Sprint(renew), "expires": expiresText}
+		printResult(format, refreshResult, func(w io.Writer) {
+			fmt.Fprintln(w, "KUBECONFIG\tRENEWED\tEXPIRES\tCLUSTER ID")
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", refreshResult["kubeconfig"], refreshResult["renewed"], refreshResult["expires"], refreshResult["clusterId"])
+		})
+
+	// token, for kubectl..
+	// stdout carries only the ExecCredential, & nothing is logged unless a token cannot be had..
+	case t2.FullCommand():
+
+		// configure file system & state store..
+		cleanUp = false
+		configDirPath := configureFileSystem(*configDir, cleanUp)
+		state := loadState(configDirPath)
+
+		// no --clusterId flag provided, using the current context..
+		*t2ClusterId = state.clusterId(*t2ClusterId)
+
+		// cached or new token..
+		token, err := clusterToken(ctx, c, configDirPath, *t2ClusterId, *t2Expiration, *t2RenewBefore)
+		if err != nil {
+			logln("OKECTL :: Error getting token for cluster", *t2ClusterId, "::", err, ":: Exiting..")
+			os.Exit(3)
+		}
+
+		// done, output exec credential..
+		err = writeExecCredential(os.Stdout, token, os.Getenv("KUBERNETES_EXEC_INFO"))
+		if err != nil {
+			logln("OKECTL :: Error writing ExecCredential ::", err, ":: Exiting..")
+			os.Exit(3)
+		}
+
+	// create node pool..
+	case c3.FullCommand():
+		// configure file system & state store..
+		cleanUp = false
+		configDirPath := configureFileSystem(*configDir, cleanUp)
+		state := loadState(configDirPath)
+
+		// no --clusterId flag provided, using the current context..
+		*c3ClusterId = state.clusterId(*c3ClusterId)
 
-			// get node pool id from nodepool.json..
-			jsonParsed, err := gabs.ParseJSON(content)
-			nodePoolId = (jsonParsed.Path("id").String())
-			*g3NodePoolId = nodePoolId[1 : len(nodePoolId)-1]
+		// get cluster compartment & version..
+		clusterResp := getCluster(ctx, c, *c3ClusterId)
+		if *c3KubeVersion == "" {
+			*c3KubeVersion = *clusterResp.KubernetesVersion
 		}
 
-		if *g3TfExternalDs == "false" {
-			fmt.Println("")
-			fmt.Println("OKECTL :: Get NodePool :: Request Parameters ...")
-			fmt.Println("-------------------------------------------------------")
-			fmt.Println("nodePoolId:", *g3NodePoolId)
-			fmt.Println("waitNodesActive:", *g3WaitNodesActive)
-			fmt.Println("tfExternalDs:", *g3TfExternalDs)
-			fmt.Println("")
+		// check version, image & shape are offered..
+		problems := preflightNodePool(getServiceOptions(ctx, c), "", *c3KubeVersion, *c3NodeImageName, *c3NodeShape)
+		if _, err := parseNodeWaitThreshold(*c3WaitNodesActive); err != nil {
+			problems = append(problems, err.Error())
+		}
+		if len(problems) > 0 {
+			logln("OKECTL :: Invalid node pool request :: Exiting..")
+			for _, problem := range problems {
+				logln(" -", problem)
+			}
+			os.Exit(3)
 		}
 
+		logln("")
+		logln("OKECTL :: Create NodePool :: Request Parameters ...")
+		logln("-------------------------------------------------------")
+		logln("configDir:", *configDir)
+		logln("clusterId:", *c3ClusterId)
+		logln("nodePoolName:", *c3NodePoolName)
+		logln("kubeVersion:", *c3KubeVersion)
+		logln("subnet1Id:", *c3Subnet1Id)
+		logln("subnet2Id:", *c3Subnet2Id)
+		logln("subnet3Id:", *c3Subnet3Id)
+		logln("nodeImageName:", *c3NodeImageName)
+		logln("nodeShape:", *c3NodeShape)
+		logln("nodeSshKey:", *c3NodeSshKey)
+		logln("quantityWkrSubnets:", *c3QuantityWkrSubnets)
+		logln("quantityPerSubnet:", *c3QuantityPerSubnet)
+		logln("waitNodesActive:", *c3WaitNodesActive)
+		logln("")
+
 		// brief pause..
 		time.Sleep(5 * time.Second)
 
-		// wait for create node completion - all nodes..
-		if *g3WaitNodesActive != "false" {
-			nodeProvisioning := true
-			// This loop continues while "nodeProvisioning" is true..
-			for nodeProvisioning {
-				getNodeLifeCycleState(ctx, c, *g3NodePoolId)
-				// if we are waiting for all nodes to be active..
-				if *g3WaitNodesActive == "all" {
-					boolLcs := (strings.Contains(nodeLifeCycleState, "ATING"))
-						if nodeLifeCycleState != "{}" {
-							if boolLcs != true {
-								nodeProvisioning = false
-							}
-						}
+		// create nodepool..
+		createNodePoolResp, err := createNodePool(ctx, c, *clusterResp.CompartmentId, *c3NodePoolName, *c3ClusterId, *c3KubeVersion, *c3NodeImageName, *c3NodeShape, *c3NodeSshKey, *c3Subnet1Id, *c3Subnet2Id, *c3Subnet3Id, *c3QuantityWkrSubnets, *c3QuantityPerSubnet, nil)
+		helpers.FatalIfError(err)
+
+		// wait for create nodepool completion..
+		workReqRespNpl := waitUntilWorkRequestComplete(ctx, c, createNodePoolResp.OpcWorkRequestId)
+		logln("OKECTL :: Create NodePool :: Complete ...")
+		nodePoolId := getResourceID(workReqRespNpl.Resources, containerengine.WorkRequestResourceActionTypeCreated, "NODEPOOL")
+
+		// wait for create node completion..
+		waitUntilNodesActive(ctx, c, *nodePoolId, nodeWaiter{*c3WaitNodesActive, *c3Timeout, *c3PollInterval, *c3MaxPollInterval, true})
+		logln("OKECTL :: Create Node(s) :: Complete ...")
+
+		// get nodepool details & create nodepool.json, making the new node pool the context's node pool..
+		nodePoolResp := getNodePool(ctx, c, *nodePoolId, state.nodePoolDir(ctx, c, *nodePoolId, true))
+
+		// done, output config data..
+		logln("")
+		logln("OKECTL :: Create NodePool :: Complete ...")
+		logln("-------------------------------------------------------")
+		printResult(format, nodePoolResp.NodePool, func(w io.Writer) { printNodePoolTable(w, nodePoolResp.NodePool) })
+
+	// get node pool..
+	case g3.FullCommand():
+		// running as a terraform external data source, take inputs from the query on stdin..
+		if *g3TfExternalDs == "true" {
+			query, err := readTfQuery(os.Stdin, tfNodePoolQueryKeys)
+			if err != nil {
+				logln("OKECTL :: Terraform query ::", err, ":: Exiting..")
+				os.Exit(3)
+			}
+			for key, value := range map[string]*string{"nodePoolId": g3NodePoolId, "clusterId": g3ClusterId, "nodePoolName": g3NodePoolName, "waitNodesActive": g3WaitNodesActive} {
+				if query[key] != "" {
+					*value = query[key]
 				}
-				// if we are waiting for any nodes to be active..
-				if *g3WaitNodesActive == "any" {
-					boolLcs := (strings.Contains(nodeLifeCycleState, "ACTIVE"))
-						if nodeLifeCycleState != "{}" {
-							if boolLcs == true {
-								nodeProvisioning = false
-							}
-						}
+			}
+			if query["timeout"] != "" {
+				*g3Timeout, err = time.ParseDuration(query["timeout"])
+				if err != nil {
+					logln("OKECTL :: Terraform query :: timeout:", err, ":: Exiting..")
+					os.Exit(3)
 				}
-					time.Sleep(15 * time.Second)
 			}
 		}
 
-		// get nodepool details & create nodepool.json..
-		getNodePool(ctx, c, *g3NodePoolId, configDirPath)
+		// configure file system & state store..
+		cleanUp = false
+		configDirPath := configureFileSystem(*configDir, cleanUp)
+		state := loadState(configDirPath)
+
+		// no --nodePoolId, find node pool in --clusterId..
+		if *g3NodePoolId == "" && *g3ClusterId != "" {
+			foundNodePoolId, err := findNodePoolId(ctx, c, *g3ClusterId, *g3NodePoolName)
+			if err != nil {
+				logln("OKECTL ::", err, ":: Exiting..")
+				os.Exit(3)
+			}
+			*g3NodePoolId = foundNodePoolId
+		}
+
+		// no --nodePoolId flag provided, using the current context..
+		*g3NodePoolId = state.nodePoolId(*g3NodePoolId)
+
+		if *g3TfExternalDs != "true" {
+			logln("")
+			logln("OKECTL :: Get NodePool :: Request Parameters ...")
+			logln("-------------------------------------------------------")
+			logln("nodePoolId:", *g3NodePoolId)
+			logln("waitNodesActive:", *g3WaitNodesActive)
+			logln("tfExternalDs:", *g3TfExternalDs)
+			logln("")
+
+			// brief pause..
+			time.Sleep(5 * time.Second)
+		}
+
+		// wait for create node completion..
+		waitUntilNodesActive(ctx, c, *g3NodePoolId, nodeWaiter{*g3WaitNodesActive, *g3Timeout, *g3PollInterval, *g3MaxPollInterval, *g3TfExternalDs != "true"})
+
+		// get nodepool details & create nodepool.json, leaving the context's node pool as it is..
+		nodePoolResp := getNodePool(ctx, c, *g3NodePoolId, state.nodePoolDir(ctx, c, *g3NodePoolId, false))
 
 		// done, output config data..
-		// if we are running as a terraform external data source, return only json data..
+		// if we are running as a terraform external data source, return only a flat map of strings..
 		if *g3TfExternalDs == "true" {
 
-			// read nodepool.json..
-			configFilePath := configDirPath + string(os.PathSeparator) + "nodepool.json"
-			content, err := ioutil.ReadFile(configFilePath)
+			// cluster endpoint & kubeconfig, where one was written by an earlier command..
+			clusterResp := getCluster(ctx, c, *nodePoolResp.ClusterId)
+			kubeconfigPath := state.kubeconfigPath(*nodePoolResp.ClusterId)
+
+			// worker node private ips..
+			compute, network := newComputeBackend(c), newNetworkBackend(c)
+			privateIps := []string{}
+			for _, node := range nodePoolResp.Nodes {
+				privateIp, err := nodePrivateIp(ctx, compute, network, *nodePoolResp.CompartmentId, node)
+				if err != nil {
+					logln("OKECTL :: Error getting private ip of node", derefString(node.Name), "::", err, ":: Exiting..")
+					os.Exit(3)
+				}
+				privateIps = append(privateIps, privateIp)
+			}
+
+			err := writeTfResult(os.Stdout, tfNodePoolResult(nodePoolResp.NodePool, clusterResp.Cluster, privateIps, kubeconfigPath))
 			if err != nil {
-				fmt.Println("OKECTL :: No --nodePoolId flag provided, error reading nodepool.json at specified path :: Exiting..")
-				fmt.Println(err)
+				logln("OKECTL :: Error writing Terraform result ::", err, ":: Exiting..")
 				os.Exit(3)
 			}
 
-			// get worker node publicip from nodepool.json..
-			jsonParsed, err := gabs.ParseJSON(content)
-			nodeIps := (jsonParsed.Path("nodes.publicIp").String())
-			nodeIp := findIP(nodeIps)
+		} else {
+			// not running as terraform external data source, return verbose output..
+			logln("")
+			logln("OKECTL :: Get NodePool :: Complete ...")
+			logln("-------------------------------------------------------")
+			printResult(format, nodePoolResp.NodePool, func(w io.Writer) { printNodePoolTable(w, nodePoolResp.NodePool) })
+		}
 
-			jsonObj := gabs.New()
-			jsonObj.Set(nodeIp, "workerNodeIp")
+	// list node pools..
+	case l3.FullCommand():
+		// configure file system & state store..
+		cleanUp = false
+		configDirPath := configureFileSystem(*configDir, cleanUp)
+		state := loadState(configDirPath)
+
+		// no --clusterId flag provided, using the current context..
+		*l3ClusterId = state.clusterId(*l3ClusterId)
+
+		// list nodepools, with node details..
+		clusterResp := getCluster(ctx, c, *l3ClusterId)
+		nodePools := []containerengine.NodePool{}
+		for _, nodePoolSummary := range listNodePools(ctx, c, *clusterResp.CompartmentId, *l3ClusterId) {
+			nodePoolResp, err := c.GetNodePool(ctx, containerengine.GetNodePoolRequest{NodePoolId: nodePoolSummary.Id})
+			helpers.FatalIfError(err)
+			nodePools = append(nodePools, nodePoolResp.NodePool)
+		}
 
-			fmt.Println(jsonObj.String())
+		// done, output nodepool data..
+		printResult(format, nodePools, func(w io.Writer) {
+			fmt.Fprintln(w, "NAME\tSHAPE\tVERSION\tIMAGE\tSUBNETS\tNODES\tNODE STATES\tNODE POOL ID")
+			for _, nodePool := range nodePools {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%d\t%s\t%s\n", derefString(nodePool.Name), derefString(nodePool.NodeShape), derefString(nodePool.KubernetesVersion),
+					derefString(nodePool.NodeImageName), len(nodePool.SubnetIds), len(nodePool.Nodes), summariseNodeStates(nodePool.Nodes), derefString(nodePool.Id))
+			}
+		})
 
-		} else {
-			// not running as terraform external data source, return verbose output..
-			fmt.Println("")
-			fmt.Println("OKECTL :: Get NodePool :: Complete ...")
-			fmt.Println("-------------------------------------------------------")
-			configFilePath := configDirPath + string(os.PathSeparator) + "nodepool.json"
-				content, err := ioutil.ReadFile(configFilePath)
-				if err != nil {
-					fmt.Println(err)
-				}
-				strNodePool := string(content)
-				fmt.Println(strNodePool)
+	// scale node pool..
+	case s3.FullCommand():
+		// configure file system & state store..
+		cleanUp = false
+		configDirPath := configureFileSystem(*configDir, cleanUp)
+		state := loadState(configDirPath)
+
+		// no --nodePoolId flag provided, using the current context..
+		*s3NodePoolId = state.nodePoolId(*s3NodePoolId)
+
+		if *s3QuantityPerSubnet < 0 {
+			logln("OKECTL :: --quantityPerSubnet must not be negative :: Exiting..")
+			os.Exit(3)
+		}
+		if _, err := parseNodeWaitThreshold(*s3WaitNodesActive); err != nil {
+			logln("OKECTL ::", err, ":: Exiting..")
+			os.Exit(3)
 		}
+
+		logln("")
+		logln("OKECTL :: Scale NodePool :: Request Parameters ...")
+		logln("-------------------------------------------------------")
+		logln("nodePoolId:", *s3NodePoolId)
+		logln("quantityPerSubnet:", *s3QuantityPerSubnet)
+		logln("waitNodesActive:", *s3WaitNodesActive)
+		logln("")
+
+		// brief pause..
+		time.Sleep(5 * time.Second)
+
+		// scale nodepool..
+		updateNodePoolResp := scaleNodePool(ctx, c, *s3NodePoolId, *s3QuantityPerSubnet)
+
+		// wait for update nodepool completion..
+		waitUntilWorkRequestComplete(ctx, c, updateNodePoolResp.OpcWorkRequestId)
+		logln("OKECTL :: Update NodePool :: Complete ...")
+
+		// wait for node completion..
+		waitUntilNodesActive(ctx, c, *s3NodePoolId, nodeWaiter{*s3WaitNodesActive, *s3Timeout, *s3PollInterval, *s3MaxPollInterval, true})
+		logln("OKECTL :: Scale Node(s) :: Complete ...")
+
+		// get nodepool details & refresh nodepool.json, making the scaled node pool the context's node pool..
+		nodePoolResp := getNodePool(ctx, c, *s3NodePoolId, state.nodePoolDir(ctx, c, *s3NodePoolId, true))
+
+		// done, output config data..
+		logln("")
+		logln("OKECTL :: Scale NodePool :: Complete ...")
+		logln("-------------------------------------------------------")
+		printResult(format, nodePoolResp.NodePool, func(w io.Writer) { printNodePoolTable(w, nodePoolResp.NodePool) })
+
+	// upgrade node pool..
+	case u3.FullCommand():
+		// configure file system & state store..
+		cleanUp = false
+		configDirPath := configureFileSystem(*configDir, cleanUp)
+		state := loadState(configDirPath)
+
+		// no --nodePoolId flag provided, using the current context..
+		*u3NodePoolId = state.nodePoolId(*u3NodePoolId)
+
+		if *u3BatchSize < 1 {
+			logln("OKECTL :: --batchSize must be at least 1 :: Exiting..")
+			os.Exit(3)
+		}
+
+		// worker nodes may not run ahead of the control plane..
+		nodePoolResp, err := c.GetNodePool(ctx, containerengine.GetNodePoolRequest{NodePoolId: u3NodePoolId})
+		helpers.FatalIfError(err)
+		clusterResp := getCluster(ctx, c, *nodePoolResp.ClusterId)
+		clusterVersion := derefString(clusterResp.KubernetesVersion)
+		if *u3KubeVersion == "" {
+			*u3KubeVersion = clusterVersion
+		}
+		if compareKubeVersions(*u3KubeVersion, clusterVersion) > 0 {
+			logln("OKECTL :: Upgrade NodePool :: Version", *u3KubeVersion, "is ahead of cluster version", clusterVersion,
+				"- upgrade the cluster first :: Exiting..")
+			os.Exit(3)
+		}
+
+		// node image can not be changed in place, as UpdateNodePool takes no image..
+		if *u3NodeImageName != "" && *u3NodeImageName != derefString(nodePoolResp.NodeImageName) {
+			logln("OKECTL :: Upgrade NodePool :: Node image", derefString(nodePoolResp.NodeImageName), "can not be changed to", *u3NodeImageName,
+				"in place by this version of the OKE API - create a node pool with the new image using createOkeNodePool, then delete this one using deleteOkeNodePool :: Exiting..")
+			os.Exit(3)
+		}
+
+		logln("")
+		logln("OKECTL :: Upgrade NodePool :: Request Parameters ...")
+		logln("-------------------------------------------------------")
+		logln("nodePoolId:", *u3NodePoolId)
+		logln("currentVersion:", derefString(nodePoolResp.KubernetesVersion))
+		logln("kubeVersion:", *u3KubeVersion)
+		logln("nodeImageName:", derefString(nodePoolResp.NodeImageName))
+		logln("nodes:", len(nodePoolResp.Nodes))
+		logln("batchSize:", *u3BatchSize)
+		logln("drainTimeout:", *u3DrainTimeout)
+		logln("nodeTimeout:", *u3NodeTimeout)
+		logln("pollInterval:", *u3PollInterval)
+		logln("maxPollInterval:", *u3MaxPollInterval)
+		logln("")
+
+		// brief pause..
+		time.Sleep(5 * time.Second)
+
+		// set node pool version..
+		if derefString(nodePoolResp.KubernetesVersion) != *u3KubeVersion {
+			updateNodePoolResp := upgradeNodePool(ctx, c, *u3NodePoolId, *u3KubeVersion)
+			waitUntilWorkRequestComplete(ctx, c, updateNodePoolResp.OpcWorkRequestId)
+			logln("OKECTL :: Update NodePool :: Complete ...")
+		}
+
+		// kubernetes access for cordon & drain..
+		contextDirPath := state.nodePoolDir(ctx, c, *u3NodePoolId, false)
+		getKubeConfig(ctx, c, *nodePoolResp.ClusterId, contextDirPath, defaultKubeconfigExpiration)
+		state.setKubeconfigExpires(*nodePoolResp.ClusterId, defaultKubeconfigExpiration)
+		var kube kubeNodeClient = fakeKubeClient{}
+		if *backend != "fake" {
+			kubeClient, err := newKubeRestClient(contextDirPath + string(os.PathSeparator) + "kubeconfig")
+			if err != nil {
+				logln("OKECTL :: Upgrade NodePool :: Error reading kubeconfig:", err, ":: Exiting..")
+				os.Exit(3)
+			}
+			// the upgrade may outlast the kubeconfig token, so requests take a cached token, renewed as it nears expiry..
+			kubeClient.tokenSource = func() (string, error) {
+				token, err := clusterToken(ctx, c, configDirPath, *nodePoolResp.ClusterId, defaultKubeconfigExpiration, time.Minute)
+				return token.Token, err
+			}
+			kube = kubeClient
+		}
+
+		// replace worker nodes..
+		err = rollNodePool(ctx, c, newComputeBackend(c), kube, *u3NodePoolId, *u3BatchSize, *u3DrainTimeout, *u3NodeTimeout, *u3PollInterval, *u3MaxPollInterval)
+		waitIfInterrupted(ctx)
+		if err != nil {
+			logln("OKECTL :: Upgrade NodePool :: Failed:", err, ":: Exiting..")
+			os.Exit(3)
+		}
+		logln("OKECTL :: Replace Node(s) :: Complete ...")
+
+		// get nodepool details & refresh nodepool.json..
+		upgradedNodePoolResp := getNodePool(ctx, c, *u3NodePoolId, contextDirPath)
+
+		// done, output nodepool data..
+		logln("")
+		logln("OKECTL :: Upgrade NodePool :: Complete ...")
+		printResult(format, upgradedNodePoolResp.NodePool, func(w io.Writer) { printNodePoolTable(w, upgradedNodePoolResp.NodePool) })
+
+	// delete node pool..
+	case d3.FullCommand():
+		// configure file system & state store..
+		cleanUp = false
+		configDirPath := configureFileSystem(*configDir, cleanUp)
+		state := loadState(configDirPath)
+
+		// no --nodePoolId flag provided, using the current context..
+		*d3NodePoolId = state.nodePoolId(*d3NodePoolId)
+
+		logln("")
+		logln("OKECTL :: Delete NodePool :: Request Parameters ...")
+		logln("-------------------------------------------------------")
+		logln("nodePoolId:", *d3NodePoolId)
+		logln("")
+
+		// brief pause..
+		time.Sleep(5 * time.Second)
+
+		// delete nodepool..
+		deleteNodePoolResp := deleteNodePool(ctx, c, *d3NodePoolId)
+
+		// wait for delete nodepool completion..
+		workReqRespNpl := waitUntilWorkRequestComplete(ctx, c, deleteNodePoolResp.OpcWorkRequestId)
+
+		// remove stale nodepool.json..
+		state.forgetNodePool(*d3NodePoolId)
+
+		// done, output work request..
+		logln("")
+		logln("OKECTL :: Delete NodePool :: Complete ...")
+		printResult(format, workReqRespNpl.WorkRequest, func(w io.Writer) { printWorkRequestTable(w, workReqRespNpl.WorkRequest) })
+
+	// list contexts..
+	case l4.FullCommand():
+
+		// configure file system & state store..
+		cleanUp = false
+		configDirPath := configureFileSystem(*configDir, cleanUp)
+		state := loadState(configDirPath)
+
+		// done, output contexts..
+		contexts := state.summaries()
+		printResult(format, contexts, func(w io.Writer) { printContextTable(w, contexts) })
+
+	// use context..
+	case u4.FullCommand():
+
+		// configure file system & state store..
+		cleanUp = false
+		configDirPath := configureFileSystem(*configDir, cleanUp)
+		state := loadState(configDirPath)
+
+		// context must already be recorded..
+		if _, ok := state.Contexts[*u4Context]; !ok {
+			names := []string{}
+			for _, contextSummary := range state.summaries() {
+				names = append(names, contextSummary.Name)
+			}
+			if len(names) == 0 {
+				logln("OKECTL :: No contexts recorded - create a cluster, or get one with getOkeCluster --clusterId :: Exiting..")
+				os.Exit(3)
+			}
+			logln("OKECTL :: Context", *u4Context, "not found - did you mean", nearestMatch(*u4Context, names)+"? contexts:", strings.Join(names, ", "), ":: Exiting..")
+			os.Exit(3)
+		}
+
+		// set current context..
+		state.update(func() { state.CurrentContext = *u4Context })
+
+		// done, output contexts..
+		logln("OKECTL :: Use Context ::", *u4Context, ":: Complete ...")
+		contexts := state.summaries()
+		printResult(format, contexts, func(w io.Writer) { printContextTable(w, contexts) })
+
+	// cancel work request..
+	case c5.FullCommand():
+
+		// configure file system & state store..
+		cleanUp = false
+		configDirPath := configureFileSystem(*configDir, cleanUp)
+		state := loadState(configDirPath)
+
+		logln("")
+		logln("OKECTL :: Cancel Work Request :: Request Parameters ...")
+		logln("-------------------------------------------------------")
+		logln("workRequestId:", *c5WorkRequestId)
+		logln("")
+
+		// cancel work request..
+		logln("OKECTL :: Cancel Work Request :: Submitted ...")
+		err := cancelWorkRequest(ctx, c, *c5WorkRequestId)
+		if err != nil {
+			logln("OKECTL :: Cancel Work Request ::", err, ":: Exiting..")
+			os.Exit(3)
+		}
+		state.update(func() { state.markWorkRequestCanceled(*c5WorkRequestId) })
+
+		// get work request status - CANCELING until OKE stops work on the resource..
+		workReqResp, err := c.GetWorkRequest(ctx, containerengine.GetWorkRequestRequest{WorkRequestId: c5WorkRequestId})
+		helpers.FatalIfError(err)
+
+		// done, output work request..
+		logln("")
+		logln("OKECTL :: Cancel Work Request :: Complete ...")
+		printResult(format, workReqResp.WorkRequest, func(w io.Writer) { printWorkRequestTable(w, workReqResp.WorkRequest) })
+
+	// create network..
+	case c6.FullCommand():
+
+		// configure file system & state store..
+		cleanUp = false
+		configDirPath := configureFileSystem(*configDir, cleanUp)
+		state := loadState(configDirPath)
+
+		// carve the vcn into subnets..
+		layout, err := planNetworkLayout(*c6CidrBlock, *c6QuantityWkrSubnets)
+		if err != nil {
+			logln("OKECTL ::", err, ":: Exiting..")
+			os.Exit(3)
+		}
+
+		logln("")
+		logln("OKECTL :: Create Network :: Request Parameters ...")
+		logln("-------------------------------------------------------")
+		logln("configDir:", *configDir)
+		logln("compartmentId:", *c6CompartmentId)
+		logln("vcnName:", *c6VcnName)
+		logln("cidrBlock:", *c6CidrBlock)
+		logln("dnsLabel:", *c6DnsLabel)
+		logln("quantityWkrSubnets:", *c6QuantityWkrSubnets)
+		logln("sshSourceCidr:", *c6SshSourceCidr)
+		logln("")
+
+		// brief pause..
+		time.Sleep(5 * time.Second)
+
+		// create network across availability domains..
+		availabilityDomainNames := availabilityDomains(ctx, newIdentityBackend(c), *c6CompartmentId)
+		// each resource is recorded in state.json as it is created, for createOkeCluster - or for clean up, should a later one fail..
+		network := createNetwork(ctx, newNetworkBackend(c), availabilityDomainNames, *c6CompartmentId, *c6VcnName, *c6CidrBlock, *c6DnsLabel, *c6SshSourceCidr, layout,
+			func(network okectlNetwork) {
+				state.update(func() { state.Network = &network })
+			})
+
+		// done, output network..
+		logln("")
+		logln("OKECTL :: Create Network :: Complete ...")
+		logln("-------------------------------------------------------")
+		printResult(format, network, func(w io.Writer) { printNetworkTable(w, network) })
 	}
 }
 
@@ -376,20 +1252,20 @@ func configureFileSystem(configDir string, cleanUp bool) (configDirPath string)
 		// find our okectl binary path..
 		dir, err := filepath.Abs(filepath.Dir(os.Args[0]))
 		if err != nil {
-			fmt.Println(err)
+			logln(err)
 		}
 		// clean-up & create configDir..
 		configDirPath = (dir + string(os.PathSeparator) + configDir)
 		if cleanUp == true {
 			err = os.RemoveAll(configDirPath)
 			if err != nil {
-				fmt.Println(err)
+				logln(err)
 			}
 		}
 		if _, err := os.Stat(configDir); err != nil {
 			err = os.MkdirAll(configDirPath, 0777)
 			if err != nil {
-				fmt.Println(err)
+				logln(err)
 			}
 		}
 	}
@@ -401,7 +1277,7 @@ func configureFileSystem(configDir string, cleanUp bool) (configDirPath string)
 		configDirPath = configDir
 		} else {
 			// specified configDir does not exist..
-			fmt.Println("OKECTL :: Directory --configDir not found :: Exiting ...")
+			logln("OKECTL :: Directory --configDir not found :: Exiting ...")
 			os.Exit(3)
 		}
 	}
@@ -412,8 +1288,8 @@ func configureFileSystem(configDir string, cleanUp bool) (configDirPath string)
 // create cluster..
 func createCluster(
 	ctx context.Context,
-	client containerengine.ContainerEngineClient,
-	clusterName, vcnId, compartmentId, kubeVersion, subnet1Id, subnet2Id string) containerengine.CreateClusterResponse {
+	client okeBackend,
+	clusterName, vcnId, compartmentId, kubeVersion, subnet1Id, subnet2Id string, isDashboardEnabled, isTillerEnabled bool) containerengine.CreateClusterResponse {
 
 	req := containerengine.CreateClusterRequest{}
 	req.Name = common.String(clusterName)
@@ -423,26 +1299,91 @@ func createCluster(
 	req.Options = &containerengine.ClusterCreateOptions{
 		ServiceLbSubnetIds: []string{subnet1Id, subnet2Id},
 		AddOns: &containerengine.AddOnOptions{
-			IsKubernetesDashboardEnabled: common.Bool(true),
-			IsTillerEnabled:              common.Bool(true),
+			IsKubernetesDashboardEnabled: common.Bool(isDashboardEnabled),
+			IsTillerEnabled:              common.Bool(isTillerEnabled),
 		},
 	}
 
-	fmt.Println("OKECTL :: Create Cluster :: Submitted ...")
+	logln("OKECTL :: Create Cluster :: Submitted ...")
 	resp, err := client.CreateCluster(ctx, req)
 	helpers.FatalIfError(err)
 
 	return resp
 }
 
+// get cluster..
+func getCluster(ctx context.Context, client okeBackend, clusterId string) containerengine.GetClusterResponse {
+
+	req := containerengine.GetClusterRequest{
+		ClusterId: common.String(clusterId),
+	}
+
+	resp, err := client.GetCluster(ctx, req)
+	helpers.FatalIfError(err)
+
+	return resp
+}
+
+// get cluster details & create cluster.json..
+func getClusterJson(
+	ctx context.Context,
+	client okeBackend,
+	clusterId, configDirPath string) containerengine.GetClusterResponse {
+
+	if *g1TfExternalDs != "true" {
+		logln("OKECTL :: Getting Cluster Data ...")
+	}
+
+	resp := getCluster(ctx, client, clusterId)
+
+	// populate cluster.json file..
+	configFilePath := configDirPath + string(os.PathSeparator) + "cluster.json"
+	clusterJsonIndent, _ := json.MarshalIndent(resp.Cluster, "", "\t")
+	err := ioutil.WriteFile(configFilePath, clusterJsonIndent, 0666)
+	if err != nil {
+		logln("OKECTL :: Error Writing cluster.json File:", err)
+	}
+	helpers.FatalIfError(err)
+
+	return resp
+}
+
+// list clusters, following pagination..
+func listClusters(ctx context.Context, client okeBackend, compartmentId, clusterName string, lifecycleStates []string) []containerengine.ClusterSummary {
+
+	req := containerengine.ListClustersRequest{
+		CompartmentId: common.String(compartmentId),
+	}
+	if clusterName != "" {
+		req.Name = common.String(clusterName)
+	}
+	for _, lifecycleState := range lifecycleStates {
+		req.LifecycleState = append(req.LifecycleState, containerengine.ListClustersLifecycleStateEnum(lifecycleState))
+	}
+
+	clusters := []containerengine.ClusterSummary{}
+	for {
+		resp, err := client.ListClusters(ctx, req)
+		helpers.FatalIfError(err)
+
+		clusters = append(clusters, resp.Items...)
+		if resp.OpcNextPage == nil {
+			break
+		}
+		req.Page = resp.OpcNextPage
+	}
+
+	return clusters
+}
+
 // delete cluster..
-func deleteCluster(ctx context.Context, client containerengine.ContainerEngineClient, clusterId string) containerengine.DeleteClusterResponse {
+func deleteCluster(ctx context.Context, client okeBackend, clusterId string) containerengine.DeleteClusterResponse {
 
 	req := containerengine.DeleteClusterRequest{
 		ClusterId: common.String(clusterId),
 	}
 
-	fmt.Println("OKECTL :: Delete Cluster :: Submitted ...")
+	logln("OKECTL :: Delete Cluster :: Submitted ...")
 	resp, err := client.DeleteCluster(ctx, req)
 	helpers.FatalIfError(err)
 
@@ -452,12 +1393,12 @@ func deleteCluster(ctx context.Context, client containerengine.ContainerEngineCl
 // create nodepool..
 func createNodePool(
 	ctx context.Context,
-	client containerengine.ContainerEngineClient,
-	compartmentId, clusterName, clusterId, kubeVersion, nodeImageName, nodeShape, nodeSshKey, subnet3Id, subnet4Id, subnet5Id string, quantityWkrSubnets, quantityPerSubnet int) containerengine.CreateNodePoolResponse {
+	client okeBackend,
+	compartmentId, nodePoolName, clusterId, kubeVersion, nodeImageName, nodeShape, nodeSshKey, subnet3Id, subnet4Id, subnet5Id string, quantityWkrSubnets, quantityPerSubnet int, initialNodeLabels []containerengine.KeyValue) (containerengine.CreateNodePoolResponse, error) {
 
 	req := containerengine.CreateNodePoolRequest{}
 	req.CompartmentId = common.String(compartmentId)
-	req.Name = common.String(clusterName)
+	req.Name = common.String(nodePoolName)
 	req.ClusterId = common.String(clusterId)
 	req.KubernetesVersion = common.String(kubeVersion)
 	req.NodeImageName = common.String(nodeImageName)
@@ -477,57 +1418,99 @@ func createNodePool(
 		req.SubnetIds = []string{subnet3Id, subnet4Id, subnet5Id}
 	}
 	req.QuantityPerSubnet = common.Int(quantityPerSubnet)
+	req.InitialNodeLabels = initialNodeLabels
 
-	fmt.Println("OKECTL :: Create NodePool :: Submitted ...")
-	resp, err := client.CreateNodePool(ctx, req)
-	helpers.FatalIfError(err)
-
-	return resp
+	logln("OKECTL :: Create NodePool :: Submitted ...")
+	return client.CreateNodePool(ctx, req)
 }
 
-// delete nodepool
-func deleteNodePool(ctx context.Context, client containerengine.ContainerEngineClient, nodePoolID *string) {
-	deleteReq := containerengine.DeleteNodePoolRequest{
-		NodePoolId: nodePoolID,
+// list nodepools in a cluster, following pagination..
+func listNodePools(ctx context.Context, client okeBackend, compartmentId, clusterId string) []containerengine.NodePoolSummary {
+
+	req := containerengine.ListNodePoolsRequest{
+		CompartmentId: common.String(compartmentId),
+		ClusterId:     common.String(clusterId),
 	}
 
-	client.DeleteNodePool(ctx, deleteReq)
+	nodePools := []containerengine.NodePoolSummary{}
+	for {
+		resp, err := client.ListNodePools(ctx, req)
+		helpers.FatalIfError(err)
+
+		nodePools = append(nodePools, resp.Items...)
+		if resp.OpcNextPage == nil {
+			break
+		}
+		req.Page = resp.OpcNextPage
+	}
 
-	fmt.Println("deleting nodepool")
+	return nodePools
 }
 
-// get worker node lifecycle status..
-func getNodeLifeCycleState(
-	ctx context.Context,
-	client containerengine.ContainerEngineClient,
-	nodePoolId string) containerengine.GetNodePoolResponse {
+// scale nodepool..
+func scaleNodePool(ctx context.Context, client okeBackend, nodePoolId string, quantityPerSubnet int) containerengine.UpdateNodePoolResponse {
 
-	req := containerengine.GetNodePoolRequest{}
-	req.NodePoolId = common.String(nodePoolId)
+	req := containerengine.UpdateNodePoolRequest{
+		NodePoolId: common.String(nodePoolId),
+	}
+	req.QuantityPerSubnet = common.Int(quantityPerSubnet)
 
-	resp, err := client.GetNodePool(ctx, req)
+	logln("OKECTL :: Update NodePool :: Submitted ...")
+	resp, err := client.UpdateNodePool(ctx, req)
 	helpers.FatalIfError(err)
 
-	// marshal & parse json..
-	nodePoolResp := resp.NodePool
-	nodesJson, _ := json.Marshal(nodePoolResp)
-	jsonParsed, _ := gabs.ParseJSON(nodesJson)
-	nodeLifeCycleState = (jsonParsed.Path("nodes.lifecycleState").String())
+	return resp
+}
+
+// delete nodepool..
+func deleteNodePool(ctx context.Context, client okeBackend, nodePoolId string) containerengine.DeleteNodePoolResponse {
+
+	req := containerengine.DeleteNodePoolRequest{
+		NodePoolId: common.String(nodePoolId),
+	}
+
+	logln("OKECTL :: Delete NodePool :: Submitted ...")
+	resp, err := client.DeleteNodePool(ctx, req)
+	helpers.FatalIfError(err)
 
 	return resp
 }
 
+// summarise node lifecycle states - e.g. ACTIVE=2,CREATING=1..
+func summariseNodeStates(nodes []containerengine.Node) string {
+	if len(nodes) == 0 {
+		return "-"
+	}
+
+	stateCounts := map[string]int{}
+	states := []string{}
+	for _, node := range nodes {
+		state := string(node.LifecycleState)
+		if stateCounts[state] == 0 {
+			states = append(states, state)
+		}
+		stateCounts[state]++
+	}
+	sort.Strings(states)
+
+	summary := []string{}
+	for _, state := range states {
+		summary = append(summary, fmt.Sprintf("%s=%d", state, stateCounts[state]))
+	}
+	return strings.Join(summary, ",")
+}
+
 // get nodepool details & create nodepool.json..
 func getNodePool(
 	ctx context.Context,
-	client containerengine.ContainerEngineClient,
+	client okeBackend,
 	nodePoolId, configDirPath string) containerengine.GetNodePoolResponse {
 
 	req := containerengine.GetNodePoolRequest{}
 	req.NodePoolId = common.String(nodePoolId)
 
 	if *g3TfExternalDs == "false" {
-		fmt.Println("OKECTL :: Getting NodePool Data ...")
+		logln("OKECTL :: Getting NodePool Data ...")
 	}
 
 	resp, err := client.GetNodePool(ctx, req)
@@ -537,7 +1520,7 @@ func getNodePool(
 	configFilePath := configDirPath + string(os.PathSeparator) + "nodepool.json"
 	file, err := os.Create(configFilePath)
 	if err != nil {
-		fmt.Println("OKECTL :: Error Creating nodepool.json File:", err)
+		logln("OKECTL :: Error Creating nodepool.json File:", err)
 	}
 	defer file.Close()
 
@@ -546,7 +1529,7 @@ func getNodePool(
 	nodesJsonIndent, _ := json.MarshalIndent(nodePoolResp, "", "\t")
 	err = ioutil.WriteFile(configFilePath, nodesJsonIndent, 0666)
 	if err != nil {
-		fmt.Println("OKECTL :: Error Writing nodepool.json File:", err)
+		logln("OKECTL :: Error Writing nodepool.json File:", err)
 	}
 	helpers.FatalIfError(err)
 
@@ -556,57 +1539,65 @@ func getNodePool(
 // create kubeconfig..
 func getKubeConfig(
 	ctx context.Context,
-	client containerengine.ContainerEngineClient,
-	clusterId, configDirPath string) containerengine.CreateKubeconfigResponse {
+	client okeBackend,
+	clusterId, configDirPath string,
+	expiration time.Duration) containerengine.CreateKubeconfigResponse {
 
 	req := containerengine.CreateKubeconfigRequest{}
 	req.ClusterId = common.String(clusterId)
-	req.Expiration = common.Int(360)
+	req.Expiration = common.Int(int(expiration.Seconds()))
 
-	fmt.Println("OKECTL :: Getting kubeconfig Data ...")
+	logln("OKECTL :: Getting kubeconfig Data ...")
 
 	// create output file..
 	configFilePath := configDirPath + string(os.PathSeparator) + "kubeconfig"
 	file, err := os.Create(configFilePath)
 	if err != nil {
-		fmt.Println("Cannot create kubeconfig file", err)
+		logln("Cannot create kubeconfig file", err)
 	}
 	defer file.Close()
 
 	// populate output file..
 	resp, err := client.CreateKubeconfig(ctx, req)
+	helpers.FatalIfError(err)
 	_, err = io.Copy(file, resp.Content)
 	if err != nil {
-		fmt.Println("OKECTL :: Error Writing kubeconfig File:", err)
+		logln("OKECTL :: Error Writing kubeconfig File:", err)
 	}
 	helpers.FatalIfError(err)
 
 	return resp
 }
 
-// locate ip address..
-func findIP(input string) string {
-	numBlock := "(25[0-5]|2[0-4][0-9]|1[0-9][0-9]|[1-9]?[0-9])"
-	regexPattern := numBlock + "\\." + numBlock + "\\." + numBlock + "\\." + numBlock
+// merge kubeconfig into another kubeconfig file, e.g. ~/.kube/config..
+func mergeKubeConfig(configDirPath, mergeInto, kubeContext string, setCurrentContext bool) {
+	mergeInto = expandHome(mergeInto)
+	logln("OKECTL :: Merging kubeconfig into", mergeInto, "...")
 
-	regEx := regexp.MustCompile(regexPattern)
-	return regEx.FindString(input)
+	backupPath, err := mergeKubeconfigFile(configDirPath+string(os.PathSeparator)+"kubeconfig", mergeInto, kubeContext, setCurrentContext)
+	if err != nil {
+		logln("OKECTL :: Error Merging kubeconfig:", err, ":: Exiting..")
+		os.Exit(3)
+	}
+	if backupPath != "" {
+		logln("OKECTL :: Previous kubeconfig kept as", backupPath)
+	}
 }
 
-// wait until work request finishes..
-func waitUntilWorkRequestComplete(client containerengine.ContainerEngineClient, workReuqestID *string) containerengine.GetWorkRequestResponse {
-	// retry GetWorkRequest call until TimeFinished is set..
-	shouldRetryFunc := func(r common.OCIOperationResponse) bool {
-		return r.Response.(containerengine.GetWorkRequestResponse).TimeFinished == nil
+// wait until work request finishes, exiting when it fails..
+func waitUntilWorkRequestComplete(ctx context.Context, client okeBackend, workReuqestID *string) containerengine.GetWorkRequestResponse {
+	getResp, err := waitForWorkRequest(ctx, client, workReuqestID)
+	waitIfInterrupted(ctx)
+	if failure, ok := err.(workRequestFailedError); ok {
+		printWorkRequestFailure(failure)
+		logln("OKECTL ::", failure, ":: Exiting..")
+		os.Exit(exitWorkRequestFailed)
 	}
-
-	getWorkReq := containerengine.GetWorkRequestRequest{
-		WorkRequestId:   workReuqestID,
-		RequestMetadata: helpers.GetRequestMetadataWithCustomizedRetryPolicy(shouldRetryFunc),
+	if err != nil {
+		logln("OKECTL ::", err, ":: Exiting..")
+		os.Exit(3)
 	}
 
-	getResp, err := client.GetWorkRequest(context.Background(), getWorkReq)
-	helpers.FatalIfError(err)
 	return getResp
 }
 
@@ -618,6 +1609,7 @@ func getResourceID(resources []containerengine.WorkRequestResource, actionType c
 		}
 	}
 
-	fmt.Println("OKECTL :: Unable to obtain Resource ID ...")
+	logln("OKECTL :: Unable to obtain", entityType, "Resource ID :: Exiting..")
+	os.Exit(3)
 	return nil
 }
\ No newline at end of file
@@ -0,0 +1,405 @@
+package main
+
+// import libraries..
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// cluster spec run through the fake backend..
+const testClusterSpec = `clusterName: test-001
+compartmentId: ocid1.compartment.oc1..fake
+vcnId: ocid1.vcn.oc1.fake
+kubeVersion: v1.11.1
+options:
+  serviceLbSubnetIds: [ocid1.subnet.oc1.fake.lb1, ocid1.subnet.oc1.fake.lb2]
+nodePools:
+  - name: general
+    nodeImageName: Oracle-Linux-7.5
+    nodeShape: VM.Standard2.1
+    subnetIds: [ocid1.subnet.oc1.fake.w1, ocid1.subnet.oc1.fake.w2]
+    quantityPerSubnet: 1
+waitNodesActive: all
+`
+
+// the test binary stands in for okectl when OKECTL_TEST_MAIN is set, so commands run end to end, exit codes included..
+func TestMain(m *testing.M) {
+	if os.Getenv("OKECTL_TEST_MAIN") == "1" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// okectlRun is a test environment - a config dir & fake backend state file, shared by successive commands..
+type okectlRun struct {
+	t         *testing.T
+	dir       string
+	configDir string
+	env       []string
+}
+
+// new test environment, with the cluster spec written to its directory..
+func newOkectlRun(t *testing.T) *okectlRun {
+	dir, err := ioutil.TempDir("", "okectl-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(filepath.Join(dir, "cluster.yaml"), []byte(testClusterSpec), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	configDir := filepath.Join(dir, ".okectl")
+	err = os.Mkdir(configDir, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &okectlRun{
+		t:         t,
+		dir:       dir,
+		configDir: configDir,
+		env:       []string{"OKECTL_TEST_MAIN=1", "OKECTL_FAKE_STATE=" + filepath.Join(dir, "fake.json")},
+	}
+}
+
+// remove the test environment..
+func (r *okectlRun) cleanUp() {
+	os.RemoveAll(r.dir)
+}
+
+// run an okectl command against the fake backend, returning stdout & the exit code..
+func (r *okectlRun) okectl(env []string, args ...string) (string, int) {
+	args = append([]string{"--backend=fake", "--configDir=" + r.configDir, "--output=json"}, args...)
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Dir = r.dir
+	cmd.Env = append(append(os.Environ(), r.env...), env...)
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+
+	err := cmd.Run()
+	exitCode := 0
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		exitCode = exitErr.ExitCode()
+	} else if err != nil {
+		r.t.Fatal(err)
+	}
+	if testing.Verbose() {
+		r.t.Logf("okectl %s :: exit %d\n%s", strings.Join(args, " "), exitCode, stderr)
+	}
+
+	return stdout.String(), exitCode
+}
+
+// read a json file of the config dir..
+func (r *okectlRun) readJson(path string, v interface{}) {
+	content, err := ioutil.ReadFile(filepath.Join(r.configDir, path))
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	err = json.Unmarshal(content, v)
+	if err != nil {
+		r.t.Fatalf("%s: %v", path, err)
+	}
+}
+
+// read the fake backend state..
+func (r *okectlRun) fakeState() fakeState {
+	state := fakeState{}
+	content, err := ioutil.ReadFile(filepath.Join(r.dir, "fake.json"))
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	err = json.Unmarshal(content, &state)
+	if err != nil {
+		r.t.Fatalf("fake.json: %v", err)
+	}
+
+	return state
+}
+
+// create a cluster, get its node pool, then delete it..
+func TestCreateGetDeleteCluster(t *testing.T) {
+	t.Parallel()
+	r := newOkectlRun(t)
+	defer r.cleanUp()
+
+	// create cluster..
+	_, exitCode := r.okectl(nil, "createOkeCluster", "--spec=cluster.yaml")
+	if exitCode != 0 {
+		t.Fatalf("createOkeCluster exited %d", exitCode)
+	}
+
+	// state.json records the cluster as the current context..
+	state := okectlState{}
+	r.readJson("state.json", &state)
+	if state.CurrentContext != "test-001" {
+		t.Fatalf("currentContext = %q, want test-001", state.CurrentContext)
+	}
+	clusterContext := state.Contexts["test-001"]
+	if clusterContext == nil || clusterContext.ClusterId == "" || clusterContext.NodePoolId == "" || clusterContext.CompartmentId != "ocid1.compartment.oc1..fake" {
+		t.Fatalf("context = %+v, want cluster, node pool & compartment ids", clusterContext)
+	}
+
+	// cluster.json, nodepool.json & kubeconfig describe the cluster..
+	cluster := map[string]interface{}{}
+	r.readJson(filepath.Join("contexts", "test-001", "cluster.json"), &cluster)
+	if cluster["id"] != clusterContext.ClusterId || cluster["name"] != "test-001" {
+		t.Fatalf("cluster.json id %v name %v, want %s test-001", cluster["id"], cluster["name"], clusterContext.ClusterId)
+	}
+	nodePool := map[string]interface{}{}
+	r.readJson(filepath.Join("contexts", "test-001", "nodepool.json"), &nodePool)
+	if nodePool["id"] != clusterContext.NodePoolId || nodePool["clusterId"] != clusterContext.ClusterId {
+		t.Fatalf("nodepool.json id %v clusterId %v, want %s %s", nodePool["id"], nodePool["clusterId"], clusterContext.NodePoolId, clusterContext.ClusterId)
+	}
+	kubeconfig, err := ioutil.ReadFile(filepath.Join(r.configDir, "contexts", "test-001", "kubeconfig"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(kubeconfig), "kind: Config") || !strings.Contains(string(kubeconfig), "server:") {
+		t.Fatalf("kubeconfig is not a kubeconfig:\n%s", kubeconfig)
+	}
+
+	// get node pool of the current context, with every node active..
+	stdout, exitCode := r.okectl(nil, "getOkeNodePool", "--waitNodesActive=all")
+	if exitCode != 0 {
+		t.Fatalf("getOkeNodePool exited %d", exitCode)
+	}
+	result := struct {
+		Id    string `json:"id"`
+		Nodes []struct {
+			LifecycleState string `json:"lifecycleState"`
+		} `json:"nodes"`
+	}{}
+	err = json.Unmarshal([]byte(stdout), &result)
+	if err != nil {
+		t.Fatalf("getOkeNodePool output: %v\n%s", err, stdout)
+	}
+	if result.Id != clusterContext.NodePoolId || len(result.Nodes) != 2 {
+		t.Fatalf("getOkeNodePool id %s with %d node(s), want %s with 2", result.Id, len(result.Nodes), clusterContext.NodePoolId)
+	}
+	for _, node := range result.Nodes {
+		if node.LifecycleState != "ACTIVE" {
+			t.Fatalf("node lifecycleState = %s, want ACTIVE", node.LifecycleState)
+		}
+	}
+
+	// delete cluster of the current context, forgetting its context..
+	stdout, exitCode = r.okectl(nil, "deleteOkeCluster")
+	if exitCode != 0 {
+		t.Fatalf("deleteOkeCluster exited %d", exitCode)
+	}
+	if !strings.Contains(stdout, `"SUCCEEDED"`) {
+		t.Fatalf("deleteOkeCluster output has no SUCCEEDED work request:\n%s", stdout)
+	}
+	state = okectlState{}
+	r.readJson("state.json", &state)
+	if _, ok := state.Contexts["test-001"]; ok {
+		t.Fatalf("state.json still has context test-001 after deleteOkeCluster")
+	}
+}
+
+// failed work requests exit with status 6..
+func TestCreateClusterWorkRequestFailure(t *testing.T) {
+	t.Parallel()
+	for _, operationType := range []string{"CLUSTER_CREATE", "NODEPOOL_CREATE"} {
+		operationType := operationType
+		t.Run(operationType, func(t *testing.T) {
+			t.Parallel()
+			r := newOkectlRun(t)
+			defer r.cleanUp()
+
+			_, exitCode := r.okectl([]string{"OKECTL_FAKE_FAIL=" + operationType}, "createOkeCluster", "--spec=cluster.yaml")
+			if exitCode != exitWorkRequestFailed {
+				t.Fatalf("createOkeCluster with %s failing exited %d, want %d", operationType, exitCode, exitWorkRequestFailed)
+			}
+		})
+	}
+}
+
+// scale a node pool to 0, then back up & down, waiting on all nodes each time..
+func TestScaleNodePool(t *testing.T) {
+	t.Parallel()
+	r := newOkectlRun(t)
+	defer r.cleanUp()
+
+	_, exitCode := r.okectl(nil, "createOkeCluster", "--spec=cluster.yaml")
+	if exitCode != 0 {
+		t.Fatalf("createOkeCluster exited %d", exitCode)
+	}
+
+	// 2 worker subnets, so 2 nodes per unit of quantityPerSubnet..
+	for _, quantityPerSubnet := range []int{0, 2, 1} {
+		stdout, exitCode := r.okectl(nil, "scaleOkeNodePool", fmt.Sprintf("--quantityPerSubnet=%d", quantityPerSubnet), "--waitNodesActive=all", "--timeout=20s", "--pollInterval=1s")
+		if exitCode != 0 {
+			t.Fatalf("scaleOkeNodePool --quantityPerSubnet=%d exited %d", quantityPerSubnet, exitCode)
+		}
+		result := struct {
+			QuantityPerSubnet int `json:"quantityPerSubnet"`
+			Nodes             []struct {
+				LifecycleState string `json:"lifecycleState"`
+			} `json:"nodes"`
+		}{}
+		err := json.Unmarshal([]byte(stdout), &result)
+		if err != nil {
+			t.Fatalf("scaleOkeNodePool output: %v\n%s", err, stdout)
+		}
+		if result.QuantityPerSubnet != quantityPerSubnet || len(result.Nodes) != 2*quantityPerSubnet {
+			t.Fatalf("scaleOkeNodePool --quantityPerSubnet=%d left quantityPerSubnet %d with %d node(s), want %d", quantityPerSubnet, result.QuantityPerSubnet, len(result.Nodes), 2*quantityPerSubnet)
+		}
+		for _, node := range result.Nodes {
+			if node.LifecycleState != "ACTIVE" {
+				t.Fatalf("scaleOkeNodePool --quantityPerSubnet=%d left a node %s, want ACTIVE", quantityPerSubnet, node.LifecycleState)
+			}
+		}
+	}
+}
+
+// a resumed run refuses to resubmit a failed work request while its resource remains, & resubmits once it is deleted..
+func TestResumeAfterWorkRequestFailure(t *testing.T) {
+	t.Parallel()
+	for _, test := range []struct{ operationType, entityType, deleteCommand, idFlag string }{
+		{"CLUSTER_CREATE", "CLUSTER", "deleteOkeCluster", "--clusterId="},
+		{"NODEPOOL_CREATE", "NODEPOOL", "deleteOkeNodePool", "--nodePoolId="},
+	} {
+		test := test
+		t.Run(test.operationType, func(t *testing.T) {
+			t.Parallel()
+			r := newOkectlRun(t)
+			defer r.cleanUp()
+
+			_, exitCode := r.okectl([]string{"OKECTL_FAKE_FAIL=" + test.operationType}, "createOkeCluster", "--spec=cluster.yaml")
+			if exitCode != exitWorkRequestFailed {
+				t.Fatalf("createOkeCluster with %s failing exited %d, want %d", test.operationType, exitCode, exitWorkRequestFailed)
+			}
+
+			// the failed resource remains, so resume refuses without creating another..
+			failed := r.fakeState()
+			_, exitCode = r.okectl(nil, "createOkeCluster", "--spec=cluster.yaml", "--resume=true")
+			if exitCode != exitWorkRequestFailed {
+				t.Fatalf("resumed createOkeCluster exited %d, want %d", exitCode, exitWorkRequestFailed)
+			}
+			refused := r.fakeState()
+			if len(refused.Clusters) != len(failed.Clusters) || len(refused.NodePools) != len(failed.NodePools) {
+				t.Fatalf("resumed createOkeCluster created resources - %d cluster(s) & %d node pool(s), want %d & %d",
+					len(refused.Clusters), len(refused.NodePools), len(failed.Clusters), len(failed.NodePools))
+			}
+
+			// delete the resource left by the failed work request, then resume..
+			resourceId := ""
+			for _, workRequest := range refused.WorkRequests {
+				if workRequest.Status == "FAILED" && derefString(workRequest.Resources[0].EntityType) == test.entityType {
+					resourceId = derefString(workRequest.Resources[0].Identifier)
+				}
+			}
+			if resourceId == "" {
+				t.Fatalf("no FAILED %s work request in the fake backend", test.operationType)
+			}
+			_, exitCode = r.okectl(nil, test.deleteCommand, test.idFlag+resourceId)
+			if exitCode != 0 {
+				t.Fatalf("%s exited %d", test.deleteCommand, exitCode)
+			}
+			_, exitCode = r.okectl(nil, "createOkeCluster", "--spec=cluster.yaml", "--resume=true")
+			if exitCode != 0 {
+				t.Fatalf("resumed createOkeCluster after %s exited %d", test.deleteCommand, exitCode)
+			}
+
+			// one cluster & one node pool of each name..
+			resumed := r.fakeState()
+			clusters, nodePools := 0, 0
+			for _, cluster := range resumed.Clusters {
+				if cluster.LifecycleState != "DELETED" && derefString(cluster.Name) == "test-001" {
+					clusters++
+				}
+			}
+			for _, nodePool := range resumed.NodePools {
+				if derefString(nodePool.Name) == "general" {
+					nodePools++
+				}
+			}
+			if clusters != 1 || nodePools != 1 {
+				t.Fatalf("resumed createOkeCluster left %d cluster(s) & %d node pool(s), want 1 & 1", clusters, nodePools)
+			}
+		})
+	}
+}
+
+// the nodepool.json, cluster.json & kubeconfig of earlier versions are moved into a context the first time the state store loads..
+func TestMigrateLegacyFiles(t *testing.T) {
+	t.Parallel()
+	r := newOkectlRun(t)
+	defer r.cleanUp()
+
+	_, exitCode := r.okectl(nil, "createOkeCluster", "--spec=cluster.yaml")
+	if exitCode != 0 {
+		t.Fatalf("createOkeCluster exited %d", exitCode)
+	}
+	created := okectlState{}
+	r.readJson("state.json", &created)
+
+	// lay the files out as earlier versions did, directly in --configDir, without state.json..
+	for _, file := range []string{"nodepool.json", "cluster.json", "kubeconfig"} {
+		err := os.Rename(filepath.Join(r.configDir, "contexts", "test-001", file), filepath.Join(r.configDir, file))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, path := range []string{"contexts", "state.json"} {
+		err := os.RemoveAll(filepath.Join(r.configDir, path))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// get node pool of the migrated context..
+	_, exitCode = r.okectl(nil, "getOkeNodePool")
+	if exitCode != 0 {
+		t.Fatalf("getOkeNodePool after migration exited %d", exitCode)
+	}
+	state := okectlState{}
+	r.readJson("state.json", &state)
+	clusterContext, want := state.Contexts["test-001"], created.Contexts["test-001"]
+	if state.CurrentContext != "test-001" || clusterContext == nil || clusterContext.ClusterId != want.ClusterId || clusterContext.CompartmentId != want.CompartmentId || clusterContext.NodePoolId != want.NodePoolId {
+		t.Fatalf("migrated state.json current context %q, context %+v, want test-001 %+v", state.CurrentContext, clusterContext, want)
+	}
+	for _, file := range []string{"nodepool.json", "cluster.json", "kubeconfig"} {
+		if _, err := os.Stat(filepath.Join(r.configDir, "contexts", "test-001", file)); err != nil {
+			t.Fatalf("%s not moved into context test-001: %v", file, err)
+		}
+		if _, err := os.Stat(filepath.Join(r.configDir, file)); !os.IsNotExist(err) {
+			t.Fatalf("%s left in --configDir after migration", file)
+		}
+	}
+}
+
+// adopting a cluster with --ifNotExists keeps the files of its context..
+func TestAdoptClusterKeepsContext(t *testing.T) {
+	t.Parallel()
+	r := newOkectlRun(t)
+	defer r.cleanUp()
+
+	_, exitCode := r.okectl(nil, "createOkeCluster", "--spec=cluster.yaml")
+	if exitCode != 0 {
+		t.Fatalf("createOkeCluster exited %d", exitCode)
+	}
+	marker := filepath.Join(r.configDir, "contexts", "test-001", "marker")
+	err := ioutil.WriteFile(marker, []byte("kept"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, exitCode = r.okectl(nil, "createOkeCluster", "--spec=cluster.yaml", "--ifNotExists=true")
+	if exitCode != 0 {
+		t.Fatalf("createOkeCluster --ifNotExists=true exited %d", exitCode)
+	}
+	if _, err := os.Stat(marker); err != nil {
+		t.Fatalf("adopting the cluster removed the files of its context: %v", err)
+	}
+}
@@ -0,0 +1,400 @@
+package main
+
+// import libraries..
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	"github.com/oracle/oci-go-sdk/containerengine"
+	"gopkg.in/yaml.v2"
+)
+
+// banners & progress go to stderr, so stdout carries only the command result..
+func logln(a ...interface{}) {
+	fmt.Fprintln(os.Stderr, a...)
+}
+
+func logf(format string, a ...interface{}) {
+	fmt.Fprintf(os.Stderr, format, a...)
+}
+
+// outputFormat is the parsed --output flag - table, json, yaml, jsonpath=EXPR or go-template=TEMPLATE..
+type outputFormat struct {
+	kind string
+	expr string
+}
+
+// parse the --output flag..
+func parseOutputFormat(output string) (outputFormat, error) {
+	switch output {
+	case "table", "json", "yaml":
+		return outputFormat{kind: output}, nil
+	}
+
+	for _, kind := range []string{"jsonpath", "go-template"} {
+		if strings.HasPrefix(output, kind+"=") {
+			expr := strings.TrimPrefix(output, kind+"=")
+			if expr == "" {
+				return outputFormat{}, fmt.Errorf("--output=%s= requires an expression", kind)
+			}
+			return outputFormat{kind: kind, expr: expr}, nil
+		}
+	}
+
+	return outputFormat{}, fmt.Errorf("--output must be table, json, yaml, jsonpath=EXPR or go-template=TEMPLATE, not %q", output)
+}
+
+// print a command result to stdout in the requested format..
+// table renders the human readable form of the result..
+func printResult(format outputFormat, result interface{}, table func(w io.Writer)) {
+	err := writeResult(os.Stdout, format, result, table)
+	if err != nil {
+		logln("OKECTL :: Error formatting output:", err, ":: Exiting..")
+		os.Exit(3)
+	}
+}
+
+// write a command result in the requested format..
+func writeResult(w io.Writer, format outputFormat, result interface{}, table func(w io.Writer)) error {
+	if format.kind == "table" {
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		table(tw)
+		return tw.Flush()
+	}
+
+	// other formats work from the json form of the result, so field names match the api..
+	content, err := json.MarshalIndent(result, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	switch format.kind {
+	case "json":
+		_, err = fmt.Fprintln(w, string(content))
+		return err
+
+	case "yaml":
+		// decode into ordered yaml, keeping json field order..
+		var ordered interface{} = &yaml.MapSlice{}
+		if bytes.HasPrefix(bytes.TrimSpace(content), []byte("[")) {
+			ordered = &[]yaml.MapSlice{}
+		}
+		err = yaml.Unmarshal(content, ordered)
+		if err != nil {
+			return err
+		}
+		content, err = yaml.Marshal(ordered)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(content)
+		return err
+
+	case "go-template":
+		tmpl, err := template.New("output").Parse(format.expr)
+		if err != nil {
+			return err
+		}
+		var data interface{}
+		err = json.Unmarshal(content, &data)
+		if err != nil {
+			return err
+		}
+		text := &bytes.Buffer{}
+		err = tmpl.Execute(text, data)
+		if err != nil {
+			return err
+		}
+		return writeLine(w, text.String())
+
+	case "jsonpath":
+		var data interface{}
+		err = json.Unmarshal(content, &data)
+		if err != nil {
+			return err
+		}
+		text, err := evalJsonPath(format.expr, data)
+		if err != nil {
+			return err
+		}
+		return writeLine(w, text)
+	}
+
+	return fmt.Errorf("unknown output format %q", format.kind)
+}
+
+// write text, ending it with a newline if it does not already have one..
+func writeLine(w io.Writer, text string) error {
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	_, err := io.WriteString(w, text)
+	return err
+}
+
+// evaluate a kubectl style jsonpath template - e.g. {.nodes[*].publicIp}..
+// supports field, index & wildcard selectors, quoted literals, and {range ...}{end}..
+func evalJsonPath(expr string, data interface{}) (string, error) {
+	tokens, err := tokeniseJsonPath(expr)
+	if err != nil {
+		return "", err
+	}
+
+	out := &bytes.Buffer{}
+	rest, err := execJsonPath(out, tokens, data, data)
+	if err != nil {
+		return "", err
+	}
+	if len(rest) > 0 {
+		return "", fmt.Errorf("jsonpath: unexpected {end}")
+	}
+
+	return out.String(), nil
+}
+
+// jsonPathToken is literal text, or an expression between braces..
+type jsonPathToken struct {
+	text string
+	expr bool
+}
+
+// split a jsonpath template into text & expressions..
+func tokeniseJsonPath(expr string) ([]jsonPathToken, error) {
+	tokens := []jsonPathToken{}
+	for len(expr) > 0 {
+		open := strings.Index(expr, "{")
+		if open < 0 {
+			tokens = append(tokens, jsonPathToken{text: expr})
+			break
+		}
+		if open > 0 {
+			tokens = append(tokens, jsonPathToken{text: expr[:open]})
+		}
+		close := strings.Index(expr[open:], "}")
+		if close < 0 {
+			return nil, fmt.Errorf("jsonpath: unclosed { in %q", expr)
+		}
+		tokens = append(tokens, jsonPathToken{text: strings.TrimSpace(expr[open+1 : open+close]), expr: true})
+		expr = expr[open+close+1:]
+	}
+
+	return tokens, nil
+}
+
+// execute tokens against the current value, up to a matching {end}..
+// returns the tokens following the {end}..
+func execJsonPath(out *bytes.Buffer, tokens []jsonPathToken, root, current interface{}) ([]jsonPathToken, error) {
+	for len(tokens) > 0 {
+		token := tokens[0]
+		tokens = tokens[1:]
+
+		switch {
+		case !token.expr:
+			out.WriteString(token.text)
+
+		case token.text == "end":
+			return tokens, nil
+
+		case strings.HasPrefix(token.text, "range "):
+			items, err := selectJsonPath(strings.TrimSpace(strings.TrimPrefix(token.text, "range ")), root, current)
+			if err != nil {
+				return nil, err
+			}
+			body := tokens
+			rest, err := execJsonPath(&bytes.Buffer{}, body, root, nil)
+			if err != nil {
+				return nil, err
+			}
+			for _, item := range items {
+				_, err = execJsonPath(out, body, root, item)
+				if err != nil {
+					return nil, err
+				}
+			}
+			tokens = rest
+
+		case strings.HasPrefix(token.text, "\""):
+			literal, err := strconv.Unquote(token.text)
+			if err != nil {
+				return nil, fmt.Errorf("jsonpath: bad literal %s", token.text)
+			}
+			out.WriteString(literal)
+
+		default:
+			values, err := selectJsonPath(token.text, root, current)
+			if err != nil {
+				return nil, err
+			}
+			for i, value := range values {
+				if i > 0 {
+					out.WriteString(" ")
+				}
+				out.WriteString(formatJsonPathValue(value))
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// select the values matched by a path - e.g. .nodes[0].name, $.items[*].id..
+// paths starting with $ are taken from the root, others from the current value..
+func selectJsonPath(path string, root, current interface{}) ([]interface{}, error) {
+	values := []interface{}{current}
+	if strings.HasPrefix(path, "$") {
+		values = []interface{}{root}
+		path = path[1:]
+	}
+	if strings.HasPrefix(path, "@") {
+		path = path[1:]
+	}
+
+	for len(path) > 0 {
+		next := []interface{}{}
+		switch {
+		case path[0] == '.':
+			end := strings.IndexAny(path[1:], ".[")
+			if end < 0 {
+				end = len(path) - 1
+			}
+			field := path[1 : end+1]
+			path = path[end+1:]
+			for _, value := range values {
+				if field == "" {
+					next = append(next, value)
+					continue
+				}
+				object, ok := value.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				if field == "*" {
+					for _, v := range object {
+						next = append(next, v)
+					}
+				} else if v, ok := object[field]; ok {
+					next = append(next, v)
+				}
+			}
+
+		case path[0] == '[':
+			end := strings.Index(path, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("jsonpath: unclosed [ in %q", path)
+			}
+			selector := path[1:end]
+			path = path[end+1:]
+			for _, value := range values {
+				array, ok := value.([]interface{})
+				if !ok {
+					continue
+				}
+				if selector == "*" {
+					next = append(next, array...)
+					continue
+				}
+				index, err := strconv.Atoi(selector)
+				if err != nil {
+					return nil, fmt.Errorf("jsonpath: bad index [%s]", selector)
+				}
+				if index < 0 {
+					index += len(array)
+				}
+				if index >= 0 && index < len(array) {
+					next = append(next, array[index])
+				}
+			}
+
+		default:
+			return nil, fmt.Errorf("jsonpath: unexpected %q", path)
+		}
+		values = next
+	}
+
+	return values, nil
+}
+
+// format a selected value - strings as-is, everything else as json..
+func formatJsonPathValue(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	content, _ := json.Marshal(value)
+	return string(content)
+}
+
+// print node pool & its nodes as a table..
+func printNodePoolTable(w io.Writer, nodePool containerengine.NodePool) {
+	fmt.Fprintln(w, "NAME\tSHAPE\tVERSION\tIMAGE\tSUBNETS\tQUANTITY PER SUBNET\tNODE POOL ID")
+	quantityPerSubnet := 0
+	if nodePool.QuantityPerSubnet != nil {
+		quantityPerSubnet = *nodePool.QuantityPerSubnet
+	}
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%d\t%s\n", derefString(nodePool.Name), derefString(nodePool.NodeShape), derefString(nodePool.KubernetesVersion),
+		derefString(nodePool.NodeImageName), len(nodePool.SubnetIds), quantityPerSubnet, derefString(nodePool.Id))
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "NODE\tLIFECYCLE STATE\tAVAILABILITY DOMAIN\tPUBLIC IP\tNODE ID")
+	for _, node := range nodePool.Nodes {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", derefString(node.Name), node.LifecycleState, derefString(node.AvailabilityDomain),
+			derefString(node.PublicIp), derefString(node.Id))
+	}
+}
+
+// print cluster as a table..
+func printClusterTable(w io.Writer, cluster containerengine.Cluster) {
+	endpoint := ""
+	if cluster.Endpoints != nil {
+		endpoint = derefString(cluster.Endpoints.Kubernetes)
+	}
+	fmt.Fprintln(w, "NAME\tVERSION\tLIFECYCLE STATE\tENDPOINT\tAVAILABLE UPGRADES\tCLUSTER ID")
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", derefString(cluster.Name), derefString(cluster.KubernetesVersion), cluster.LifecycleState,
+		endpoint, strings.Join(cluster.AvailableKubernetesUpgrades, ","), derefString(cluster.Id))
+}
+
+// print work request as a table..
+func printWorkRequestTable(w io.Writer, workRequest containerengine.WorkRequest) {
+	fmt.Fprintln(w, "OPERATION\tSTATUS\tRESOURCE\tWORK REQUEST ID")
+	for _, resource := range workRequest.Resources {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", workRequest.OperationType, workRequest.Status, derefString(resource.Identifier), derefString(workRequest.Id))
+	}
+}
+
+// print local state store contexts as a table..
+func printContextTable(w io.Writer, contexts []contextSummary) {
+	fmt.Fprintln(w, "CURRENT\tNAME\tCLUSTER ID\tNODE POOL ID\tDIRECTORY")
+	for _, c := range contexts {
+		current := ""
+		if c.Current {
+			current = "*"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", current, c.Name, c.ClusterId, c.NodePoolId, c.Directory)
+	}
+}
+
+// print the resources created by a failed run & whether each was cleaned up..
+func printRollbackTable(w io.Writer, report rollbackReport) {
+	fmt.Fprintln(w, "TYPE\tNAME\tCLEANED UP\tERROR\tID")
+	for _, resource := range report.Resources {
+		fmt.Fprintf(w, "%s\t%s\t%t\t%s\t%s\n", resource.EntityType, resource.Name, resource.CleanedUp, resource.Error, resource.Id)
+	}
+}
+
+// print network & its subnets as a table..
+func printNetworkTable(w io.Writer, network okectlNetwork) {
+	fmt.Fprintln(w, "RESOURCE\tNAME\tAVAILABILITY DOMAIN\tCIDR BLOCK\tID")
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", "vcn", network.VcnName, "", "", network.VcnId)
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", "internetGateway", network.VcnName+"-igw", "", "", network.InternetGatewayId)
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", "routeTable", network.VcnName+"-routes", "", "", network.RouteTableId)
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", "securityList", network.VcnName+"-workers", "", "", network.WorkerSecurityListId)
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", "securityList", network.VcnName+"-loadbalancers", "", "", network.LoadBalancerSecurityListId)
+	for _, subnet := range network.Subnets {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", subnet.Role+"Subnet", subnet.Name, subnet.AvailabilityDomain, subnet.CidrBlock, subnet.Id)
+	}
+}
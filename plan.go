@@ -0,0 +1,208 @@
+package main
+
+// import libraries..
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/oracle/oci-go-sdk/containerengine"
+	"github.com/oracle/oci-go-sdk/example/helpers"
+)
+
+// plan actions, in increasing order of disruption..
+const (
+	planNoOp    = "no-op"
+	planUpdate  = "update"
+	planCreate  = "create"
+	planDelete  = "delete"
+	planReplace = "replace"
+)
+
+// resourcePlan is the planned action for the cluster or a single node pool..
+type resourcePlan struct {
+	Resource string       `json:"resource"`
+	Name     string       `json:"name"`
+	Id       string       `json:"id"`
+	Action   string       `json:"action"`
+	Changes  []planChange `json:"changes"`
+}
+
+// planChange is a single field that differs between the spec & the live resource..
+type planChange struct {
+	Field   string `json:"field"`
+	Live    string `json:"live"`
+	Desired string `json:"desired"`
+	Action  string `json:"action"`
+}
+
+// compare a cluster spec to the live cluster & node pools..
+// clusterId may be empty, in which case everything in the spec is to be created..
+func planCluster(ctx context.Context, client okeBackend, spec clusterSpec, clusterId string) []resourcePlan {
+	plans := []resourcePlan{}
+
+	// cluster..
+	if clusterId == "" {
+		plans = append(plans, resourcePlan{Resource: "cluster", Name: spec.ClusterName, Action: planCreate, Changes: []planChange{}})
+		for _, nodePool := range spec.NodePools {
+			plans = append(plans, resourcePlan{Resource: "nodePool", Name: nodePool.Name, Action: planCreate, Changes: []planChange{}})
+		}
+		return plans
+	}
+
+	cluster := getCluster(ctx, client, clusterId).Cluster
+	clusterPlan := resourcePlan{Resource: "cluster", Name: spec.ClusterName, Id: clusterId, Changes: []planChange{}}
+	clusterPlan.compare("kubernetesVersion", derefString(cluster.KubernetesVersion), spec.KubeVersion, planUpdate)
+	clusterPlan.compare("name", derefString(cluster.Name), spec.ClusterName, planUpdate)
+	clusterPlan.compare("compartmentId", derefString(cluster.CompartmentId), spec.CompartmentId, planReplace)
+	clusterPlan.compare("vcnId", derefString(cluster.VcnId), spec.VcnId, planReplace)
+	liveLbSubnetIds := []string{}
+	if cluster.Options != nil {
+		liveLbSubnetIds = cluster.Options.ServiceLbSubnetIds
+	}
+	clusterPlan.compare("serviceLbSubnetIds", joinSorted(liveLbSubnetIds), joinSorted(spec.Options.ServiceLbSubnetIds), planReplace)
+	plans = append(plans, clusterPlan)
+
+	// node pools, matched by name..
+	liveNodePools := map[string]containerengine.NodePool{}
+	for _, nodePoolSummary := range listNodePools(ctx, client, derefString(cluster.CompartmentId), clusterId) {
+		resp, err := client.GetNodePool(ctx, containerengine.GetNodePoolRequest{NodePoolId: nodePoolSummary.Id})
+		helpers.FatalIfError(err)
+		liveNodePools[derefString(resp.Name)] = resp.NodePool
+	}
+	for _, nodePoolSpec := range spec.NodePools {
+		nodePool, ok := liveNodePools[nodePoolSpec.Name]
+		if !ok {
+			plans = append(plans, resourcePlan{Resource: "nodePool", Name: nodePoolSpec.Name, Action: planCreate, Changes: []planChange{}})
+			continue
+		}
+		delete(liveNodePools, nodePoolSpec.Name)
+
+		nodePoolPlan := resourcePlan{Resource: "nodePool", Name: nodePoolSpec.Name, Id: derefString(nodePool.Id), Changes: []planChange{}}
+		nodePoolPlan.compare("kubernetesVersion", derefString(nodePool.KubernetesVersion), nodePoolSpec.KubeVersion, planUpdate)
+		nodePoolPlan.compare("nodeShape", derefString(nodePool.NodeShape), nodePoolSpec.NodeShape, planReplace)
+		nodePoolPlan.compare("nodeImageName", derefString(nodePool.NodeImageName), nodePoolSpec.NodeImageName, planReplace)
+		nodePoolPlan.compare("subnetIds", joinSorted(nodePool.SubnetIds), joinSorted(nodePoolSpec.SubnetIds), planUpdate)
+		liveQuantityPerSubnet := 0
+		if nodePool.QuantityPerSubnet != nil {
+			liveQuantityPerSubnet = *nodePool.QuantityPerSubnet
+		}
+		nodePoolPlan.compare("quantityPerSubnet", strconv.Itoa(liveQuantityPerSubnet), strconv.Itoa(nodePoolSpec.QuantityPerSubnet), planUpdate)
+		nodePoolPlan.compare("initialNodeLabels", joinLabels(nodePool.InitialNodeLabels), joinLabels(nodeLabels(nodePoolSpec.InitialNodeLabels)), planUpdate)
+		plans = append(plans, nodePoolPlan)
+	}
+
+	// live node pools missing from the spec..
+	names := []string{}
+	for name := range liveNodePools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		plans = append(plans, resourcePlan{Resource: "nodePool", Name: name, Id: derefString(liveNodePools[name].Id), Action: planDelete, Changes: []planChange{}})
+	}
+
+	return plans
+}
+
+// record a field change, raising the resource action where needed..
+func (p *resourcePlan) compare(field, live, desired, action string) {
+	if p.Action == "" {
+		p.Action = planNoOp
+	}
+	if live == desired {
+		return
+	}
+
+	p.Changes = append(p.Changes, planChange{Field: field, Live: live, Desired: desired, Action: action})
+	if planSeverity(action) > planSeverity(p.Action) {
+		p.Action = action
+	}
+}
+
+// rank plan actions by disruption..
+func planSeverity(action string) int {
+	switch action {
+	case planUpdate:
+		return 1
+	case planCreate, planDelete:
+		return 2
+	case planReplace:
+		return 3
+	}
+	return 0
+}
+
+// print plan in human readable form..
+func printPlan(w io.Writer, plans []resourcePlan) {
+	symbols := map[string]string{planNoOp: " ", planUpdate: "~", planCreate: "+", planDelete: "-", planReplace: "-/+"}
+	descriptions := map[string]string{planNoOp: "no changes", planUpdate: "update in-place", planCreate: "create", planDelete: "delete", planReplace: "replace"}
+	counts := map[string]int{}
+
+	for _, p := range plans {
+		counts[p.Action]++
+		label := p.Resource + " " + p.Name
+		if p.Id != "" {
+			label += " (" + p.Id + ")"
+		}
+		fmt.Fprintf(w, "%s %s :: %s\n", symbols[p.Action], label, descriptions[p.Action])
+		for _, change := range p.Changes {
+			note := "update in-place"
+			if change.Action == planReplace {
+				note = "forces replacement"
+			}
+			fmt.Fprintf(w, "    %s %s: %q -> %q (%s)\n", symbols[change.Action], change.Field, change.Live, change.Desired, note)
+		}
+	}
+
+	fmt.Fprintln(w, "")
+	fmt.Fprintf(w, "Plan: %d to create, %d to update in-place, %d to replace, %d to delete, %d unchanged.\n",
+		counts[planCreate], counts[planUpdate], counts[planReplace], counts[planDelete], counts[planNoOp])
+}
+
+// find a live cluster by name in a compartment..
+// returns an empty id when there is no such cluster..
+func findClusterId(ctx context.Context, client okeBackend, compartmentId, clusterName string) string {
+	clusters := listClusters(ctx, client, compartmentId, clusterName, []string{"CREATING", "ACTIVE", "UPDATING"})
+	if len(clusters) > 1 {
+		logln("OKECTL :: Found", len(clusters), "clusters named", clusterName, ":: Using", derefString(clusters[0].Id))
+	}
+	if len(clusters) == 0 {
+		return ""
+	}
+
+	return derefString(clusters[0].Id)
+}
+
+// ids of a cluster's node pools, by name..
+// where names repeat, the first node pool listed is used..
+func findNodePoolIds(ctx context.Context, client okeBackend, compartmentId, clusterId string) map[string]string {
+	nodePoolIds := map[string]string{}
+	for _, nodePool := range listNodePools(ctx, client, compartmentId, clusterId) {
+		name := derefString(nodePool.Name)
+		if _, found := nodePoolIds[name]; !found {
+			nodePoolIds[name] = derefString(nodePool.Id)
+		}
+	}
+
+	return nodePoolIds
+}
+
+// sorted, comma separated list..
+func joinSorted(values []string) string {
+	sorted := append([]string{}, values...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ",")
+}
+
+// sorted, comma separated key=value list..
+func joinLabels(labels []containerengine.KeyValue) string {
+	pairs := []string{}
+	for _, label := range labels {
+		pairs = append(pairs, derefString(label.Key)+"="+derefString(label.Value))
+	}
+	return joinSorted(pairs)
+}
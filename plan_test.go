@@ -0,0 +1,123 @@
+package main
+
+// import libraries..
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/oracle/oci-go-sdk/common"
+	"github.com/oracle/oci-go-sdk/containerengine"
+)
+
+// fake backend with a live cluster & its node pools "general" & "legacy"..
+func testPlanBackend() *fakeBackend {
+	client := newFakeBackend("")
+	client.state.Clusters["ocid1.cluster.oc1.fake.plan"] = &containerengine.Cluster{
+		Id:                common.String("ocid1.cluster.oc1.fake.plan"),
+		Name:              common.String("plan"),
+		CompartmentId:     common.String("ocid1.compartment.oc1..fake"),
+		VcnId:             common.String("ocid1.vcn.oc1.fake.plan"),
+		KubernetesVersion: common.String("v1.12.7"),
+		Options:           &containerengine.ClusterCreateOptions{ServiceLbSubnetIds: []string{"ocid1.subnet.oc1.fake.lb2", "ocid1.subnet.oc1.fake.lb1"}},
+		LifecycleState:    containerengine.ClusterLifecycleStateActive,
+	}
+	for _, name := range []string{"general", "legacy"} {
+		client.state.NodePools["ocid1.nodepool.oc1.fake."+name] = &containerengine.NodePool{
+			Id:                common.String("ocid1.nodepool.oc1.fake." + name),
+			Name:              common.String(name),
+			CompartmentId:     common.String("ocid1.compartment.oc1..fake"),
+			ClusterId:         common.String("ocid1.cluster.oc1.fake.plan"),
+			KubernetesVersion: common.String("v1.12.7"),
+			NodeImageName:     common.String("Oracle-Linux-7.6"),
+			NodeShape:         common.String("VM.Standard2.1"),
+			QuantityPerSubnet: common.Int(1),
+			SubnetIds:         []string{"ocid1.subnet.oc1.fake.w1", "ocid1.subnet.oc1.fake.w2"},
+			InitialNodeLabels: []containerengine.KeyValue{{Key: common.String("tier"), Value: common.String(name)}},
+			Nodes:             []containerengine.Node{},
+		}
+	}
+
+	return client
+}
+
+// spec matching the live cluster & both node pools..
+func testPlanSpec() clusterSpec {
+	nodePool := func(name string) nodePoolSpec {
+		return nodePoolSpec{
+			Name:              name,
+			KubeVersion:       "v1.12.7",
+			NodeImageName:     "Oracle-Linux-7.6",
+			NodeShape:         "VM.Standard2.1",
+			SubnetIds:         []string{"ocid1.subnet.oc1.fake.w2", "ocid1.subnet.oc1.fake.w1"},
+			QuantityPerSubnet: 1,
+			InitialNodeLabels: map[string]string{"tier": name},
+		}
+	}
+
+	return clusterSpec{
+		ClusterName:   "plan",
+		CompartmentId: "ocid1.compartment.oc1..fake",
+		VcnId:         "ocid1.vcn.oc1.fake.plan",
+		KubeVersion:   "v1.12.7",
+		Options:       clusterSpecOptions{ServiceLbSubnetIds: []string{"ocid1.subnet.oc1.fake.lb1", "ocid1.subnet.oc1.fake.lb2"}},
+		NodePools:     []nodePoolSpec{nodePool("general"), nodePool("legacy")},
+	}
+}
+
+// plan actions & changed fields follow the diff between spec & live resources..
+func TestPlanCluster(t *testing.T) {
+	tests := []struct {
+		name        string
+		clusterId   string
+		change      func(spec *clusterSpec)
+		wantActions []string
+		wantFields  [][]string
+	}{
+		{"no cluster", "", func(spec *clusterSpec) {},
+			[]string{planCreate, planCreate, planCreate}, [][]string{nil, nil, nil}},
+		{"unchanged", "ocid1.cluster.oc1.fake.plan", func(spec *clusterSpec) {},
+			[]string{planNoOp, planNoOp, planNoOp}, [][]string{nil, nil, nil}},
+		{"cluster version", "ocid1.cluster.oc1.fake.plan", func(spec *clusterSpec) { spec.KubeVersion = "v1.13.5" },
+			[]string{planUpdate, planNoOp, planNoOp}, [][]string{{"kubernetesVersion"}, nil, nil}},
+		{"cluster vcn", "ocid1.cluster.oc1.fake.plan", func(spec *clusterSpec) { spec.VcnId = "ocid1.vcn.oc1.fake.other" },
+			[]string{planReplace, planNoOp, planNoOp}, [][]string{{"vcnId"}, nil, nil}},
+		{"version & lb subnets", "ocid1.cluster.oc1.fake.plan", func(spec *clusterSpec) {
+			spec.KubeVersion = "v1.13.5"
+			spec.Options.ServiceLbSubnetIds = []string{"ocid1.subnet.oc1.fake.lb1"}
+		}, []string{planReplace, planNoOp, planNoOp}, [][]string{{"kubernetesVersion", "serviceLbSubnetIds"}, nil, nil}},
+		{"node pool scaled", "ocid1.cluster.oc1.fake.plan", func(spec *clusterSpec) { spec.NodePools[0].QuantityPerSubnet = 3 },
+			[]string{planNoOp, planUpdate, planNoOp}, [][]string{nil, {"quantityPerSubnet"}, nil}},
+		{"node pool labels", "ocid1.cluster.oc1.fake.plan", func(spec *clusterSpec) { spec.NodePools[1].InitialNodeLabels["zone"] = "a" },
+			[]string{planNoOp, planNoOp, planUpdate}, [][]string{nil, nil, {"initialNodeLabels"}}},
+		{"node pool shape", "ocid1.cluster.oc1.fake.plan", func(spec *clusterSpec) { spec.NodePools[0].NodeShape = "VM.Standard2.2" },
+			[]string{planNoOp, planReplace, planNoOp}, [][]string{nil, {"nodeShape"}, nil}},
+		{"node pool removed", "ocid1.cluster.oc1.fake.plan", func(spec *clusterSpec) { spec.NodePools = spec.NodePools[:1] },
+			[]string{planNoOp, planNoOp, planDelete}, [][]string{nil, nil, nil}},
+		{"node pool renamed", "ocid1.cluster.oc1.fake.plan", func(spec *clusterSpec) { spec.NodePools[1].Name = "batch" },
+			[]string{planNoOp, planNoOp, planCreate, planDelete}, [][]string{nil, nil, nil, nil}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			spec := testPlanSpec()
+			test.change(&spec)
+
+			plans := planCluster(context.Background(), testPlanBackend(), spec, test.clusterId)
+			actions, fields := []string{}, [][]string{}
+			for _, plan := range plans {
+				actions = append(actions, plan.Action)
+				var changed []string
+				for _, change := range plan.Changes {
+					changed = append(changed, change.Field)
+				}
+				fields = append(fields, changed)
+			}
+			if !reflect.DeepEqual(actions, test.wantActions) {
+				t.Errorf("actions = %v, want %v", actions, test.wantActions)
+			}
+			if !reflect.DeepEqual(fields, test.wantFields) {
+				t.Errorf("changed fields = %v, want %v", fields, test.wantFields)
+			}
+		})
+	}
+}
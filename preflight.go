@@ -0,0 +1,131 @@
+package main
+
+// import libraries..
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/oracle/oci-go-sdk/common"
+	"github.com/oracle/oci-go-sdk/containerengine"
+	"github.com/oracle/oci-go-sdk/example/helpers"
+)
+
+// serviceOptions are the kubernetes versions, images & shapes offered by OKE..
+type serviceOptions struct {
+	ClusterVersions  []string
+	NodePoolVersions []string
+	Images           []string
+	Shapes           []string
+}
+
+// get the options offered for all clusters & node pools..
+func getServiceOptions(ctx context.Context, client okeBackend) serviceOptions {
+	clusterOptions, err := client.GetClusterOptions(ctx, containerengine.GetClusterOptionsRequest{ClusterOptionId: common.String("all")})
+	helpers.FatalIfError(err)
+	nodePoolOptions, err := client.GetNodePoolOptions(ctx, containerengine.GetNodePoolOptionsRequest{NodePoolOptionId: common.String("all")})
+	helpers.FatalIfError(err)
+
+	return serviceOptions{
+		ClusterVersions:  clusterOptions.KubernetesVersions,
+		NodePoolVersions: nodePoolOptions.KubernetesVersions,
+		Images:           nodePoolOptions.Images,
+		Shapes:           nodePoolOptions.Shapes,
+	}
+}
+
+// check a cluster spec against the service options, before anything is submitted..
+// returns a description of each value the service does not offer..
+func preflightClusterSpec(options serviceOptions, spec clusterSpec) []string {
+	problems := []string{}
+
+	if problem := checkOffered("kubeVersion", spec.KubeVersion, options.ClusterVersions); problem != "" {
+		problems = append(problems, problem)
+	}
+	for i, nodePool := range spec.NodePools {
+		problems = append(problems, preflightNodePool(options, fmt.Sprintf("nodePools[%d].", i), nodePool.KubeVersion, nodePool.NodeImageName, nodePool.NodeShape)...)
+	}
+
+	return problems
+}
+
+// check node pool version, image & shape against the service options..
+// field names in problems are given the prefix, e.g. nodePools[0]...
+func preflightNodePool(options serviceOptions, prefix, kubeVersion, nodeImageName, nodeShape string) []string {
+	problems := []string{}
+
+	if problem := checkOffered(prefix+"kubeVersion", kubeVersion, options.NodePoolVersions); problem != "" {
+		problems = append(problems, problem)
+	}
+	if problem := checkOffered(prefix+"nodeImageName", nodeImageName, options.Images); problem != "" {
+		problems = append(problems, problem)
+	}
+	if problem := checkOffered(prefix+"nodeShape", nodeShape, options.Shapes); problem != "" {
+		problems = append(problems, problem)
+	}
+
+	return problems
+}
+
+// describe a value missing from the offered choices, suggesting the nearest match..
+// returns an empty string when the value is offered..
+func checkOffered(field, value string, offered []string) string {
+	for _, choice := range offered {
+		if choice == value {
+			return ""
+		}
+	}
+
+	problem := fmt.Sprintf("%s %q is not offered by the service", field, value)
+	if nearest := nearestMatch(value, offered); nearest != "" {
+		problem += fmt.Sprintf(" - did you mean %q?", nearest)
+	}
+	return problem + " valid choices: " + strings.Join(offered, ", ")
+}
+
+// closest choice by case-insensitive edit distance..
+func nearestMatch(value string, choices []string) string {
+	nearest, nearestDistance := "", -1
+	for _, choice := range choices {
+		distance := editDistance(strings.ToLower(value), strings.ToLower(choice))
+		if nearestDistance < 0 || distance < nearestDistance {
+			nearest, nearestDistance = choice, distance
+		}
+	}
+
+	return nearest
+}
+
+// levenshtein distance between two strings..
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous = current
+	}
+
+	return previous[len(b)]
+}
+
+// smallest of several ints..
+func minInt(first int, rest ...int) int {
+	min := first
+	for _, n := range rest {
+		if n < min {
+			min = n
+		}
+	}
+	return min
+}
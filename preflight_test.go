@@ -0,0 +1,99 @@
+package main
+
+// import libraries..
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// edit distance counts insertions, deletions & substitutions..
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"abc", "", 3},
+		{"v1.12.7", "v1.12.7", 0},
+		{"v1.12.7", "v1.12.6", 1},
+		{"v1.12", "v1.12.7", 2},
+		{"kitten", "sitting", 3},
+	}
+	for _, test := range tests {
+		if got := editDistance(test.a, test.b); got != test.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+// the nearest choice ignores case, preferring the first of equally near choices..
+func TestNearestMatch(t *testing.T) {
+	tests := []struct {
+		value   string
+		choices []string
+		want    string
+	}{
+		{"v1.12.8", fakeKubernetesVersions, "v1.12.6"},
+		{"v1.12.77", fakeKubernetesVersions, "v1.12.7"},
+		{"1.13.5", fakeKubernetesVersions, "v1.13.5"},
+		{"vm.standard2.1", fakeNodeShapes, "VM.Standard2.1"},
+		{"Oracle-Linux-7.7", fakeNodeImages, "Oracle-Linux-7.4"},
+		{"anything", []string{}, ""},
+	}
+	for _, test := range tests {
+		if got := nearestMatch(test.value, test.choices); got != test.want {
+			t.Errorf("nearestMatch(%q) = %q, want %q", test.value, got, test.want)
+		}
+	}
+}
+
+// offered values pass, others are described with a suggestion & the valid choices..
+func TestCheckOffered(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		offered []string
+		want    string
+	}{
+		{"offered", "VM.Standard2.1", []string{"VM.Standard2.1", "VM.Standard2.2"}, ""},
+		{"case differs", "vm.standard2.1", []string{"VM.Standard2.1", "VM.Standard2.2"},
+			`nodeShape "vm.standard2.1" is not offered by the service - did you mean "VM.Standard2.1"? valid choices: VM.Standard2.1, VM.Standard2.2`},
+		{"nothing offered", "VM.Standard2.1", []string{},
+			`nodeShape "VM.Standard2.1" is not offered by the service valid choices: `},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := checkOffered("nodeShape", test.value, test.offered); got != test.want {
+				t.Fatalf("checkOffered = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+// every unoffered value in a spec is reported, prefixed with its node pool..
+func TestPreflightClusterSpec(t *testing.T) {
+	options := serviceOptions{
+		ClusterVersions:  fakeKubernetesVersions,
+		NodePoolVersions: fakeKubernetesVersions,
+		Images:           fakeNodeImages,
+		Shapes:           fakeNodeShapes,
+	}
+	spec := clusterSpec{
+		KubeVersion: "v1.12.8",
+		NodePools: []nodePoolSpec{
+			{KubeVersion: "v1.12.7", NodeImageName: "Oracle-Linux-7.6", NodeShape: "VM.Standard2.1"},
+			{KubeVersion: "v1.12.7", NodeImageName: "Oracle-Linux-7.6", NodeShape: "VM.Standard2.3"},
+		},
+	}
+
+	problems := preflightClusterSpec(options, spec)
+	fields := []string{}
+	for _, problem := range problems {
+		fields = append(fields, strings.Fields(problem)[0])
+	}
+	if want := []string{"kubeVersion", "nodePools[1].nodeShape"}; !reflect.DeepEqual(fields, want) {
+		t.Fatalf("problems for %v, want %v - %v", fields, want, problems)
+	}
+}
@@ -0,0 +1,201 @@
+package main
+
+// import libraries..
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/common"
+	"github.com/oracle/oci-go-sdk/containerengine"
+)
+
+// time allowed to roll back the resources of a failed run..
+const rollbackTimeout = 30 * time.Minute
+
+// createdResource is a resource created during a createOkeCluster run..
+type createdResource struct {
+	EntityType string `json:"entityType"`
+	Id         string `json:"id"`
+	Name       string `json:"name"`
+	CleanedUp  bool   `json:"cleanedUp"`
+	Error      string `json:"error,omitempty"`
+}
+
+// rollbackReport is output when a createOkeCluster run fails..
+type rollbackReport struct {
+	Failure    string            `json:"failure"`
+	RolledBack bool              `json:"rolledBack"`
+	Resources  []createdResource `json:"resources"`
+}
+
+// createRun tracks every resource created during a createOkeCluster run..
+// with rollback, a failed run deletes them in reverse order before exiting, waiting on each work request.
+// without, a failed run exits as it always has, leaving them in place..
+type createRun struct {
+	rollback    bool
+	format      outputFormat
+	state       *okectlState
+	contextName string
+	journal     *createJournal
+	created     []createdResource
+}
+
+// record a created resource, once..
+func (r *createRun) track(entityType, id, name string) {
+	for _, resource := range r.created {
+		if resource.Id == id {
+			return
+		}
+	}
+	r.created = append(r.created, createdResource{EntityType: entityType, Id: id, Name: name})
+}
+
+// record the state store context of the cluster, removed if the cluster is rolled back..
+func (r *createRun) trackContext(state *okectlState, name string) {
+	r.state = state
+	r.contextName = name
+}
+
+// record the resources of a failed work request - e.g. a cluster left FAILED by a failed create..
+func (r *createRun) trackWorkRequest(workRequest containerengine.WorkRequest, name string) {
+	for _, resource := range workRequest.Resources {
+		if resource.Identifier == nil {
+			continue
+		}
+		switch entityType := strings.ToUpper(derefString(resource.EntityType)); entityType {
+		case "CLUSTER", "NODEPOOL":
+			r.track(entityType, *resource.Identifier, name)
+		}
+	}
+}
+
+// wait until a work request completes..
+// on failure, exits - first rolling back when enabled..
+func (r *createRun) waitUntilWorkRequestComplete(ctx context.Context, client okeBackend, workRequestId *string, name string) containerengine.GetWorkRequestResponse {
+	getResp, err := waitForWorkRequest(ctx, client, workRequestId)
+	if failure, ok := err.(workRequestFailedError); ok {
+		printWorkRequestFailure(failure)
+		r.trackWorkRequest(failure.workRequest, name)
+		r.fail(ctx, client, failure, exitWorkRequestFailed)
+	}
+	if err != nil {
+		r.fail(ctx, client, err, 3)
+	}
+
+	return getResp
+}
+
+// wait until nodes are active..
+// on failure, exits - first rolling back when enabled..
+func (r *createRun) waitUntilNodesActive(ctx context.Context, client okeBackend, nodePoolId string, waiter nodeWaiter) {
+	_, err := waiter.wait(ctx, client, nodePoolId)
+	switch err.(type) {
+	case nil:
+		return
+	case nodesFailedError:
+		r.fail(ctx, client, fmt.Errorf("nodepool %s: %v", nodePoolId, err), exitNodesFailed)
+	case nodesTimeoutError:
+		r.fail(ctx, client, fmt.Errorf("nodepool %s: %v", nodePoolId, err), exitNodesTimeout)
+	default:
+		r.fail(ctx, client, fmt.Errorf("nodepool %s: %v", nodePoolId, err), 3)
+	}
+}
+
+// check an error, exiting on failure - first rolling back when enabled..
+func (r *createRun) check(ctx context.Context, client okeBackend, err error) {
+	if err != nil {
+		r.fail(ctx, client, err, 3)
+	}
+}
+
+// exit with the failure's exit code, first rolling back & reporting when enabled..
+func (r *createRun) fail(ctx context.Context, client okeBackend, failure error, exitCode int) {
+	waitIfInterrupted(ctx)
+	if !r.rollback {
+		logln("OKECTL ::", failure, ":: Exiting..")
+		os.Exit(exitCode)
+	}
+
+	logln("OKECTL ::", failure, ":: Rolling back..")
+	logln("")
+	logln("OKECTL :: Rollback ::", len(r.created), "resource(s) created during this run ...")
+	// the run's context may be cancelled, so deletes are waited on with their own..
+	rollbackCtx, cancel := context.WithTimeout(context.Background(), rollbackTimeout)
+	r.rollBack(rollbackCtx, client)
+	cancel()
+
+	report := rollbackReport{Failure: failure.Error(), RolledBack: true, Resources: r.created}
+	for _, resource := range r.created {
+		if !resource.CleanedUp {
+			report.RolledBack = false
+		}
+	}
+	// nothing left to resume..
+	if report.RolledBack && r.journal != nil {
+		r.journal.remove()
+	}
+
+	logln("")
+	logln("OKECTL :: Rollback :: Complete ...")
+	logln("-------------------------------------------------------")
+	printResult(r.format, report, func(w io.Writer) { printRollbackTable(w, report) })
+	os.Exit(exitCode)
+}
+
+// delete created resources, newest first..
+// node pools go before their cluster, & a failed delete is recorded & the next resource tried..
+func (r *createRun) rollBack(ctx context.Context, client okeBackend) {
+	for i := len(r.created) - 1; i >= 0; i-- {
+		resource := &r.created[i]
+		logln("OKECTL :: Rollback :: Delete", resource.EntityType, resource.Name, resource.Id, "...")
+
+		err := r.delete(ctx, client, resource)
+		if err != nil {
+			logln("OKECTL :: Rollback :: Delete", resource.EntityType, resource.Id, "failed:", err)
+			resource.Error = err.Error()
+			continue
+		}
+		resource.CleanedUp = true
+	}
+
+	// a cluster deleted, its context goes too..
+	for _, resource := range r.created {
+		if resource.EntityType == "CLUSTER" && resource.CleanedUp && r.state != nil {
+			if c, ok := r.state.Contexts[r.contextName]; ok && c.ClusterId == resource.Id {
+				r.state.deleteContext(r.contextName)
+			}
+		}
+	}
+}
+
+// delete a resource & wait for its work request..
+func (r *createRun) delete(ctx context.Context, client okeBackend, resource *createdResource) error {
+	var workRequestId *string
+	switch resource.EntityType {
+	case "NODEPOOL":
+		resp, err := client.DeleteNodePool(ctx, containerengine.DeleteNodePoolRequest{NodePoolId: common.String(resource.Id)})
+		if err != nil {
+			return err
+		}
+		workRequestId = resp.OpcWorkRequestId
+	case "CLUSTER":
+		resp, err := client.DeleteCluster(ctx, containerengine.DeleteClusterRequest{ClusterId: common.String(resource.Id)})
+		if err != nil {
+			return err
+		}
+		workRequestId = resp.OpcWorkRequestId
+	default:
+		return fmt.Errorf("unknown resource type %s", resource.EntityType)
+	}
+
+	_, err := waitForWorkRequest(ctx, client, workRequestId)
+	if err == nil && resource.EntityType == "NODEPOOL" && r.state != nil {
+		r.state.forgetNodePool(resource.Id)
+	}
+
+	return err
+}
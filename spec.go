@@ -0,0 +1,312 @@
+package main
+
+// import libraries..
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/oracle/oci-go-sdk/common"
+	"github.com/oracle/oci-go-sdk/containerengine"
+	"gopkg.in/alecthomas/kingpin.v2"
+	"gopkg.in/yaml.v2"
+)
+
+// clusterSpec is the declarative description of a cluster, its options & node pools..
+// it is read from the file given to --spec, or assembled from the createOkeCluster flags..
+type clusterSpec struct {
+	ClusterName     string             `yaml:"clusterName" json:"clusterName"`
+	CompartmentId   string             `yaml:"compartmentId" json:"compartmentId"`
+	VcnId           string             `yaml:"vcnId" json:"vcnId"`
+	KubeVersion     string             `yaml:"kubeVersion" json:"kubeVersion"`
+	Options         clusterSpecOptions `yaml:"options" json:"options"`
+	NodePools       []nodePoolSpec     `yaml:"nodePools" json:"nodePools"`
+	WaitNodesActive string             `yaml:"waitNodesActive" json:"waitNodesActive"`
+}
+
+// clusterSpecOptions are the cluster create options..
+// add-ons are enabled unless explicitly disabled..
+type clusterSpecOptions struct {
+	ServiceLbSubnetIds         []string `yaml:"serviceLbSubnetIds" json:"serviceLbSubnetIds"`
+	KubernetesDashboardEnabled *bool    `yaml:"kubernetesDashboardEnabled" json:"kubernetesDashboardEnabled"`
+	TillerEnabled              *bool    `yaml:"tillerEnabled" json:"tillerEnabled"`
+}
+
+// nodePoolSpec describes a single node pool..
+// name defaults to the cluster name, & kubeVersion to the cluster version..
+type nodePoolSpec struct {
+	Name              string            `yaml:"name" json:"name"`
+	KubeVersion       string            `yaml:"kubeVersion" json:"kubeVersion"`
+	NodeImageName     string            `yaml:"nodeImageName" json:"nodeImageName"`
+	NodeShape         string            `yaml:"nodeShape" json:"nodeShape"`
+	NodeSshKey        string            `yaml:"nodeSshKey" json:"nodeSshKey"`
+	SubnetIds         []string          `yaml:"subnetIds" json:"subnetIds"`
+	QuantityPerSubnet int               `yaml:"quantityPerSubnet" json:"quantityPerSubnet"`
+	InitialNodeLabels map[string]string `yaml:"initialNodeLabels" json:"initialNodeLabels"`
+}
+
+// read cluster spec file..
+// unknown fields & mistyped values are rejected..
+func loadClusterSpec(specPath string) clusterSpec {
+	spec := clusterSpec{}
+
+	content, err := ioutil.ReadFile(specPath)
+	if err != nil {
+		logln("OKECTL :: Error reading --spec file at specified path :: Exiting..")
+		logln(err)
+		os.Exit(3)
+	}
+
+	err = yaml.UnmarshalStrict(content, &spec)
+	if err != nil {
+		logln("OKECTL :: Error parsing --spec file :: Exiting..")
+		logln(err)
+		os.Exit(3)
+	}
+
+	return spec
+}
+
+// assemble cluster spec from createOkeCluster flags..
+func clusterSpecFromFlags() clusterSpec {
+	spec := clusterSpec{
+		ClusterName:     *c1ClusterName,
+		CompartmentId:   *c1CompartmentId,
+		VcnId:           *c1VcnId,
+		KubeVersion:     *c1KubeVersion,
+		WaitNodesActive: *c1WaitNodesActive,
+		Options: clusterSpecOptions{
+			ServiceLbSubnetIds: []string{*c1Subnet1Id, *c1Subnet2Id},
+		},
+		NodePools: []nodePoolSpec{{
+			NodeImageName:     *c1NodeImageName,
+			NodeShape:         *c1NodeShape,
+			NodeSshKey:        *c1NodeSshKey,
+			SubnetIds:         workerSubnetIds(*c1QuantityWkrSubnets, *c1Subnet3Id, *c1Subnet4Id, *c1Subnet5Id),
+			QuantityPerSubnet: *c1QuantityPerSubnet,
+		}},
+	}
+
+	return spec
+}
+
+// override cluster spec with createOkeCluster flags set on the command line..
+// node pool flags apply to the first node pool in the spec..
+func applyClusterSpecFlags(spec *clusterSpec, flagsSet map[string]bool) {
+	if flagsSet["clusterName"] {
+		spec.ClusterName = *c1ClusterName
+	}
+	if flagsSet["compartmentId"] {
+		spec.CompartmentId = *c1CompartmentId
+	}
+	if flagsSet["vcnId"] {
+		spec.VcnId = *c1VcnId
+	}
+	if flagsSet["kubeVersion"] {
+		spec.KubeVersion = *c1KubeVersion
+	}
+	if flagsSet["waitNodesActive"] {
+		spec.WaitNodesActive = *c1WaitNodesActive
+	}
+
+	// control plane lb subnets..
+	if flagsSet["subnet1Id"] || flagsSet["subnet2Id"] {
+		for len(spec.Options.ServiceLbSubnetIds) < 2 {
+			spec.Options.ServiceLbSubnetIds = append(spec.Options.ServiceLbSubnetIds, "")
+		}
+		if flagsSet["subnet1Id"] {
+			spec.Options.ServiceLbSubnetIds[0] = *c1Subnet1Id
+		}
+		if flagsSet["subnet2Id"] {
+			spec.Options.ServiceLbSubnetIds[1] = *c1Subnet2Id
+		}
+	}
+
+	// first node pool..
+	if flagsSet["nodeImageName"] || flagsSet["nodeShape"] || flagsSet["nodeSshKey"] || flagsSet["quantityPerSubnet"] ||
+		flagsSet["subnet3Id"] || flagsSet["subnet4Id"] || flagsSet["subnet5Id"] || flagsSet["quantityWkrSubnets"] {
+		if len(spec.NodePools) == 0 {
+			spec.NodePools = append(spec.NodePools, nodePoolSpec{})
+		}
+		nodePool := &spec.NodePools[0]
+		if flagsSet["nodeImageName"] {
+			nodePool.NodeImageName = *c1NodeImageName
+		}
+		if flagsSet["nodeShape"] {
+			nodePool.NodeShape = *c1NodeShape
+		}
+		if flagsSet["nodeSshKey"] {
+			nodePool.NodeSshKey = *c1NodeSshKey
+		}
+		if flagsSet["quantityPerSubnet"] {
+			nodePool.QuantityPerSubnet = *c1QuantityPerSubnet
+		}
+
+		// worker subnets..
+		subnetIds := append([]string{}, nodePool.SubnetIds...)
+		for len(subnetIds) < 3 {
+			subnetIds = append(subnetIds, "")
+		}
+		flagSubnetIds := []string{*c1Subnet3Id, *c1Subnet4Id, *c1Subnet5Id}
+		for i, flagName := range []string{"subnet3Id", "subnet4Id", "subnet5Id"} {
+			if flagsSet[flagName] {
+				subnetIds[i] = flagSubnetIds[i]
+			}
+		}
+		if flagsSet["quantityWkrSubnets"] {
+			nodePool.SubnetIds = workerSubnetIds(*c1QuantityWkrSubnets, subnetIds[0], subnetIds[1], subnetIds[2])
+		} else {
+			nodePool.SubnetIds = []string{}
+			for _, subnetId := range subnetIds {
+				if subnetId != "" {
+					nodePool.SubnetIds = append(nodePool.SubnetIds, subnetId)
+				}
+			}
+		}
+	}
+}
+
+// fill in defaults & validate cluster spec..
+// returns a description of each problem found..
+func validateClusterSpec(spec *clusterSpec) []string {
+	problems := []string{}
+
+	if spec.ClusterName == "" {
+		problems = append(problems, "clusterName is required")
+	}
+	if spec.CompartmentId == "" {
+		problems = append(problems, "compartmentId is required")
+	}
+	if spec.VcnId == "" {
+		problems = append(problems, "vcnId is required")
+	}
+	if spec.KubeVersion == "" {
+		problems = append(problems, "kubeVersion is required")
+	}
+	if spec.WaitNodesActive == "" {
+		spec.WaitNodesActive = "false"
+	}
+	if _, err := parseNodeWaitThreshold(spec.WaitNodesActive); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if len(spec.Options.ServiceLbSubnetIds) != 2 || spec.Options.ServiceLbSubnetIds[0] == "" || spec.Options.ServiceLbSubnetIds[1] == "" {
+		problems = append(problems, "options.serviceLbSubnetIds requires exactly 2 subnet ids (--subnet1Id & --subnet2Id)")
+	}
+	if spec.Options.KubernetesDashboardEnabled == nil {
+		spec.Options.KubernetesDashboardEnabled = common.Bool(true)
+	}
+	if spec.Options.TillerEnabled == nil {
+		spec.Options.TillerEnabled = common.Bool(true)
+	}
+
+	// node pools..
+	if len(spec.NodePools) == 0 {
+		problems = append(problems, "at least one node pool is required")
+	}
+	nodePoolNames := map[string]bool{}
+	for i := range spec.NodePools {
+		nodePool := &spec.NodePools[i]
+		if nodePool.Name == "" {
+			nodePool.Name = spec.ClusterName
+			if i > 0 {
+				nodePool.Name = fmt.Sprintf("%s-%d", spec.ClusterName, i+1)
+			}
+		}
+		if nodePool.KubeVersion == "" {
+			nodePool.KubeVersion = spec.KubeVersion
+		}
+		if nodePoolNames[nodePool.Name] {
+			problems = append(problems, fmt.Sprintf("nodePools[%d].name %q is not unique", i, nodePool.Name))
+		}
+		nodePoolNames[nodePool.Name] = true
+		if nodePool.NodeImageName == "" {
+			problems = append(problems, fmt.Sprintf("nodePools[%d].nodeImageName is required", i))
+		}
+		if nodePool.NodeShape == "" {
+			problems = append(problems, fmt.Sprintf("nodePools[%d].nodeShape is required", i))
+		}
+		if len(nodePool.SubnetIds) < 1 || len(nodePool.SubnetIds) > 3 {
+			problems = append(problems, fmt.Sprintf("nodePools[%d].subnetIds requires between 1 & 3 subnet ids", i))
+		}
+		for j, subnetId := range nodePool.SubnetIds {
+			if subnetId == "" {
+				problems = append(problems, fmt.Sprintf("nodePools[%d].subnetIds[%d] is empty", i, j))
+			}
+		}
+		if nodePool.QuantityPerSubnet < 1 {
+			problems = append(problems, fmt.Sprintf("nodePools[%d].quantityPerSubnet must be at least 1", i))
+		}
+	}
+
+	return problems
+}
+
+// worker subnet ids for the requested number of worker subnets..
+func workerSubnetIds(quantityWkrSubnets int, subnet3Id, subnet4Id, subnet5Id string) []string {
+	subnetIds := []string{subnet3Id, subnet4Id, subnet5Id}
+	if quantityWkrSubnets < 0 {
+		quantityWkrSubnets = 0
+	}
+	if quantityWkrSubnets > len(subnetIds) {
+		quantityWkrSubnets = len(subnetIds)
+	}
+
+	return subnetIds[:quantityWkrSubnets]
+}
+
+// node labels as sdk key/value pairs, in key order..
+func nodeLabels(labels map[string]string) []containerengine.KeyValue {
+	keys := []string{}
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	keyValues := []containerengine.KeyValue{}
+	for _, key := range keys {
+		keyValues = append(keyValues, containerengine.KeyValue{Key: common.String(key), Value: common.String(labels[key])})
+	}
+
+	return keyValues
+}
+
+// names of flags given on the command line..
+func flagsSetByUser() map[string]bool {
+	flagsSet := map[string]bool{}
+
+	parseContext, err := app.ParseContext(os.Args[1:])
+	if err != nil {
+		return flagsSet
+	}
+	for _, element := range parseContext.Elements {
+		if flag, ok := element.Clause.(*kingpin.FlagClause); ok {
+			flagsSet[flag.Model().Name] = true
+		}
+	}
+
+	return flagsSet
+}
+
+// print cluster spec as request parameters..
+func printClusterSpec(spec clusterSpec) {
+	logln("clusterName:", spec.ClusterName)
+	logln("kubeVersion:", spec.KubeVersion)
+	logln("vcnId:", spec.VcnId)
+	logln("compartmentId:", spec.CompartmentId)
+	logln("serviceLbSubnetIds:", strings.Join(spec.Options.ServiceLbSubnetIds, ", "))
+	logln("kubernetesDashboardEnabled:", *spec.Options.KubernetesDashboardEnabled)
+	logln("tillerEnabled:", *spec.Options.TillerEnabled)
+	for i, nodePool := range spec.NodePools {
+		logf("nodePools[%d].name: %s\n", i, nodePool.Name)
+		logf("nodePools[%d].kubeVersion: %s\n", i, nodePool.KubeVersion)
+		logf("nodePools[%d].nodeImageName: %s\n", i, nodePool.NodeImageName)
+		logf("nodePools[%d].nodeShape: %s\n", i, nodePool.NodeShape)
+		logf("nodePools[%d].nodeSshKey: %s\n", i, nodePool.NodeSshKey)
+		logf("nodePools[%d].subnetIds: %s\n", i, strings.Join(nodePool.SubnetIds, ", "))
+		logf("nodePools[%d].quantityPerSubnet: %d\n", i, nodePool.QuantityPerSubnet)
+		logf("nodePools[%d].initialNodeLabels: %v\n", i, nodePool.InitialNodeLabels)
+	}
+	logln("waitNodesActive:", spec.WaitNodesActive)
+}
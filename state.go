@@ -0,0 +1,343 @@
+package main
+
+// import libraries..
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/common"
+	"github.com/oracle/oci-go-sdk/containerengine"
+	"github.com/oracle/oci-go-sdk/example/helpers"
+)
+
+// okectlState is the local state store, kept in state.json within --configDir..
+// each cluster okectl knows about is a context, keyed by cluster name, with its own directory holding
+// cluster.json, nodepool.json & kubeconfig. commands run without --clusterId or --nodePoolId use the
+// current context..
+// interruptedWorkRequests are the work requests left in flight by interrupted runs..
+// network is the network last created by createOkeNetwork..
+// changes are made through update, as the interrupt handler shares the store with the running command..
+type okectlState struct {
+	CurrentContext          string                    `json:"currentContext"`
+	Contexts                map[string]*okectlContext `json:"contexts"`
+	InterruptedWorkRequests []interruptedWorkRequest  `json:"interruptedWorkRequests,omitempty"`
+	Network                 *okectlNetwork            `json:"network,omitempty"`
+	configDirPath           string
+	mu                      sync.Mutex
+}
+
+// okectlContext records the ids of a cluster..
+// nodePoolId is the node pool used by node pool commands - the first created, or the last created or scaled..
+// kubeconfigExpires is when the token in the context's kubeconfig expires..
+type okectlContext struct {
+	ClusterId         string     `json:"clusterId"`
+	CompartmentId     string     `json:"compartmentId"`
+	NodePoolId        string     `json:"nodePoolId"`
+	KubeconfigExpires *time.Time `json:"kubeconfigExpires,omitempty"`
+}
+
+// load state.json, or start an empty state store, shared with the interrupt handler..
+// a new state store adopts the nodepool.json & kubeconfig written to --configDir by earlier versions..
+func loadState(configDirPath string) *okectlState {
+	state := &okectlState{Contexts: map[string]*okectlContext{}, configDirPath: configDirPath}
+
+	content, err := ioutil.ReadFile(filepath.Join(configDirPath, "state.json"))
+	if err == nil {
+		err = json.Unmarshal(content, state)
+		if err != nil {
+			logln("OKECTL :: Error reading state.json:", err, ":: Exiting..")
+			os.Exit(3)
+		}
+		if state.Contexts == nil {
+			state.Contexts = map[string]*okectlContext{}
+		}
+	} else if os.IsNotExist(err) {
+		state.migrateLegacyFiles()
+	}
+	shareState(state)
+
+	return state
+}
+
+// move the nodepool.json, cluster.json & kubeconfig of earlier versions from --configDir into a context, made current..
+// the context is named after the cluster where cluster.json was written, otherwise the node pool - which earlier
+// versions named after the cluster..
+func (s *okectlState) migrateLegacyFiles() {
+	content, err := ioutil.ReadFile(filepath.Join(s.configDirPath, "nodepool.json"))
+	if err != nil {
+		return
+	}
+	nodePool := containerengine.NodePool{}
+	err = json.Unmarshal(content, &nodePool)
+	if err != nil || derefString(nodePool.ClusterId) == "" {
+		logln("OKECTL :: Ignoring", filepath.Join(s.configDirPath, "nodepool.json"), "of an earlier version - no cluster id ..")
+		return
+	}
+
+	name := derefString(nodePool.Name)
+	cluster := containerengine.Cluster{}
+	content, err = ioutil.ReadFile(filepath.Join(s.configDirPath, "cluster.json"))
+	if err == nil && json.Unmarshal(content, &cluster) == nil && derefString(cluster.Id) == *nodePool.ClusterId && derefString(cluster.Name) != "" {
+		name = derefString(cluster.Name)
+	}
+	if name == "" {
+		name = "cluster-" + idSuffix(derefString(nodePool.ClusterId))
+	}
+	dir := s.contextDir(name, false)
+	for _, file := range []string{"nodepool.json", "cluster.json", "kubeconfig"} {
+		err = os.Rename(filepath.Join(s.configDirPath, file), filepath.Join(dir, file))
+		if err != nil && !os.IsNotExist(err) {
+			logln("OKECTL :: Error moving", file, "into context", name+":", err)
+		}
+	}
+	s.setContext(name, okectlContext{ClusterId: *nodePool.ClusterId, CompartmentId: derefString(nodePool.CompartmentId), NodePoolId: derefString(nodePool.Id)}, true)
+	logln("OKECTL :: Moved nodepool.json & kubeconfig of an earlier version into context", name, "-", dir, "..")
+}
+
+// apply a change & write state.json..
+// changes & writes are serialised, so the interrupt handler can record work requests while the command runs..
+func (s *okectlState) update(change func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	change()
+	content, _ := json.MarshalIndent(s, "", "\t")
+	err := ioutil.WriteFile(filepath.Join(s.configDirPath, "state.json"), content, 0666)
+	if err != nil {
+		logln("OKECTL :: Error Writing state.json File:", err)
+	}
+	helpers.FatalIfError(err)
+}
+
+// path of the directory holding a context's files..
+func (s *okectlState) contextPath(name string) string {
+	return filepath.Join(s.configDirPath, "contexts", strings.NewReplacer("/", "_", "\\", "_").Replace(name))
+}
+
+// directory holding a context's files, created if missing..
+// with cleanUp, files left by an earlier cluster of the same name are removed..
+func (s *okectlState) contextDir(name string, cleanUp bool) string {
+	dir := s.contextPath(name)
+	if cleanUp {
+		err := os.RemoveAll(dir)
+		if err != nil {
+			logln(err)
+		}
+	}
+	err := os.MkdirAll(dir, 0777)
+	if err != nil {
+		logln("OKECTL :: Error Creating context directory:", err)
+	}
+
+	return dir
+}
+
+// record a context, making it current when asked to or when there is no current context..
+func (s *okectlState) setContext(name string, c okectlContext, makeCurrent bool) {
+	s.update(func() {
+		s.Contexts[name] = &c
+		if makeCurrent || s.CurrentContext == "" {
+			s.CurrentContext = name
+		}
+	})
+}
+
+// remove a context & its files..
+func (s *okectlState) deleteContext(name string) {
+	err := os.RemoveAll(s.contextPath(name))
+	if err != nil {
+		logln(err)
+	}
+	s.update(func() {
+		delete(s.Contexts, name)
+		if s.CurrentContext == name {
+			s.CurrentContext = ""
+		}
+	})
+}
+
+// name of the context for a cluster..
+func (s *okectlState) contextName(clusterId string) (string, bool) {
+	for name, c := range s.Contexts {
+		if c.ClusterId == clusterId {
+			return name, true
+		}
+	}
+
+	return "", false
+}
+
+// the current context, exiting when there is none..
+// flag names the flag that would have been used instead, for the error message..
+func (s *okectlState) current(flag string) *okectlContext {
+	c, ok := s.Contexts[s.CurrentContext]
+	if !ok {
+		logln("OKECTL :: No --" + flag + " flag provided, and no current context - create a cluster or run useContext :: Exiting..")
+		os.Exit(3)
+	}
+
+	return c
+}
+
+// cluster id from a flag, or the current context..
+func (s *okectlState) clusterId(flagValue string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+
+	return s.current("clusterId").ClusterId
+}
+
+// node pool id from a flag, or the current context..
+func (s *okectlState) nodePoolId(flagValue string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+
+	c := s.current("nodePoolId")
+	if c.NodePoolId == "" {
+		logln("OKECTL :: No --nodePoolId flag provided, and context", s.CurrentContext, "has no node pool :: Exiting..")
+		os.Exit(3)
+	}
+
+	return c.NodePoolId
+}
+
+// directory of the context for a cluster..
+// clusters not yet in the state store are added, named after the cluster..
+func (s *okectlState) clusterDir(ctx context.Context, client okeBackend, clusterId string) string {
+	name, ok := s.contextName(clusterId)
+	if !ok {
+		cluster := getCluster(ctx, client, clusterId)
+		name = derefString(cluster.Name)
+		if existing, taken := s.Contexts[name]; taken && existing.ClusterId != clusterId {
+			name = fmt.Sprintf("%s-%s", name, idSuffix(clusterId))
+		}
+		s.setContext(name, okectlContext{ClusterId: clusterId, CompartmentId: derefString(cluster.CompartmentId)}, false)
+	}
+
+	return s.contextDir(name, false)
+}
+
+// directory of the context for a node pool's cluster, whose nodepool.json is about to describe the node pool..
+// with selectNodePool - on create & scale - the node pool becomes the context's node pool, as used by node pool
+// commands. otherwise, the context's node pool is left as it is..
+func (s *okectlState) nodePoolDir(ctx context.Context, client okeBackend, nodePoolId string, selectNodePool bool) string {
+	resp, err := client.GetNodePool(ctx, containerengine.GetNodePoolRequest{NodePoolId: common.String(nodePoolId)})
+	helpers.FatalIfError(err)
+
+	dir := s.clusterDir(ctx, client, derefString(resp.ClusterId))
+	if selectNodePool {
+		s.setNodePool(derefString(resp.ClusterId), nodePoolId)
+	}
+
+	return dir
+}
+
+// record the node pool used by node pool commands for its cluster's context, saying so where it changes..
+func (s *okectlState) setNodePool(clusterId, nodePoolId string) {
+	name, ok := s.contextName(clusterId)
+	if !ok {
+		return
+	}
+	if s.Contexts[name].NodePoolId != nodePoolId {
+		logln("OKECTL :: Context", name, ":: NodePool set to", nodePoolId, "- used by node pool commands run without --nodePoolId ..")
+	}
+	s.update(func() { s.Contexts[name].NodePoolId = nodePoolId })
+}
+
+// absolute path of a cluster's kubeconfig, where its context has one - otherwise empty..
+func (s *okectlState) kubeconfigPath(clusterId string) string {
+	name, ok := s.contextName(clusterId)
+	if !ok {
+		return ""
+	}
+	path := filepath.Join(s.contextPath(name), "kubeconfig")
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+
+	return absPath(path)
+}
+
+// record the expiry of a kubeconfig token just written to a context directory..
+func (s *okectlState) setKubeconfigExpires(clusterId string, expiration time.Duration) {
+	name, ok := s.contextName(clusterId)
+	if !ok {
+		return
+	}
+	expires := time.Now().Add(expiration).UTC().Truncate(time.Second)
+	s.update(func() { s.Contexts[name].KubeconfigExpires = &expires })
+}
+
+// forget a deleted node pool, clearing it from any context it was the node pool of, & removing any nodepool.json describing it..
+func (s *okectlState) forgetNodePool(nodePoolId string) {
+	s.update(func() {
+		for _, c := range s.Contexts {
+			if c.NodePoolId == nodePoolId {
+				c.NodePoolId = ""
+			}
+		}
+	})
+
+	for name := range s.Contexts {
+		nodePoolPath := filepath.Join(s.contextPath(name), "nodepool.json")
+		nodePool := struct {
+			Id string `json:"id"`
+		}{}
+		content, err := ioutil.ReadFile(nodePoolPath)
+		if err != nil || json.Unmarshal(content, &nodePool) != nil || nodePool.Id != nodePoolId {
+			continue
+		}
+		err = os.Remove(nodePoolPath)
+		if err != nil {
+			logln("OKECTL :: Error Removing nodepool.json File:", err)
+		}
+	}
+}
+
+// contextSummary is a context as listed by listContexts..
+type contextSummary struct {
+	Name      string `json:"name"`
+	Current   bool   `json:"current"`
+	Directory string `json:"directory"`
+	okectlContext
+}
+
+// contexts, sorted by name..
+func (s *okectlState) summaries() []contextSummary {
+	names := []string{}
+	for name := range s.Contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	summaries := []contextSummary{}
+	for _, name := range names {
+		summaries = append(summaries, contextSummary{
+			Name:          name,
+			Current:       name == s.CurrentContext,
+			Directory:     s.contextPath(name),
+			okectlContext: *s.Contexts[name],
+		})
+	}
+
+	return summaries
+}
+
+// last 6 characters of an id, distinguishing contexts of clusters with the same name..
+func idSuffix(id string) string {
+	if len(id) <= 6 {
+		return id
+	}
+
+	return id[len(id)-6:]
+}
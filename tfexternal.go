@@ -0,0 +1,171 @@
+package main
+
+// import libraries..
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/oracle/oci-go-sdk/common"
+	"github.com/oracle/oci-go-sdk/containerengine"
+	"github.com/oracle/oci-go-sdk/core"
+)
+
+// query keys accepted from terraform by getOkeNodePool..
+var tfNodePoolQueryKeys = []string{"nodePoolId", "clusterId", "nodePoolName", "waitNodesActive", "timeout"}
+
+// read the terraform external data source query - a json object of strings..
+// an empty stdin, or a terminal, is an empty query..
+func readTfQuery(stdin *os.File, allowedKeys []string) (map[string]string, error) {
+	query := map[string]string{}
+
+	info, err := stdin.Stat()
+	if err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		return query, nil
+	}
+	content, err := ioutil.ReadAll(stdin)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(string(content)) == "" {
+		return query, nil
+	}
+
+	err = json.Unmarshal(content, &query)
+	if err != nil {
+		return nil, fmt.Errorf("query must be a json object of strings: %v", err)
+	}
+	for key := range query {
+		allowed := false
+		for _, allowedKey := range allowedKeys {
+			if key == allowedKey {
+				allowed = true
+			}
+		}
+		if !allowed {
+			return nil, fmt.Errorf("unknown query key %q - expected one of %s", key, strings.Join(allowedKeys, ", "))
+		}
+	}
+
+	return query, nil
+}
+
+// find a cluster's node pool by name, or its only node pool when no name is given..
+func findNodePoolId(ctx context.Context, client okeBackend, clusterId, nodePoolName string) (string, error) {
+	cluster := getCluster(ctx, client, clusterId)
+	nodePools := listNodePools(ctx, client, derefString(cluster.CompartmentId), clusterId)
+
+	matches := []string{}
+	for _, nodePool := range nodePools {
+		if nodePoolName == "" || derefString(nodePool.Name) == nodePoolName {
+			matches = append(matches, derefString(nodePool.Id))
+		}
+	}
+	switch {
+	case len(matches) == 1:
+		return matches[0], nil
+	case nodePoolName == "":
+		return "", fmt.Errorf("cluster %s has %d node pools - specify nodePoolName or nodePoolId", clusterId, len(nodePools))
+	case len(matches) == 0:
+		return "", fmt.Errorf("cluster %s has no node pool named %q", clusterId, nodePoolName)
+	}
+	return "", fmt.Errorf("cluster %s has %d node pools named %q - specify nodePoolId", clusterId, len(matches), nodePoolName)
+}
+
+// private ip of a worker node, from the primary vnic of its instance..
+func nodePrivateIp(ctx context.Context, compute computeBackend, network networkBackend, compartmentId string, node containerengine.Node) (string, error) {
+	attachments, err := compute.ListVnicAttachments(ctx, core.ListVnicAttachmentsRequest{
+		CompartmentId: common.String(compartmentId),
+		InstanceId:    node.Id,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	privateIp := ""
+	for _, attachment := range attachments.Items {
+		if attachment.LifecycleState != core.VnicAttachmentLifecycleStateAttached || attachment.VnicId == nil {
+			continue
+		}
+		vnic, err := network.GetVnic(ctx, core.GetVnicRequest{VnicId: attachment.VnicId})
+		if err != nil {
+			return "", err
+		}
+		if vnic.IsPrimary != nil && *vnic.IsPrimary {
+			return derefString(vnic.PrivateIp), nil
+		}
+		if privateIp == "" {
+			privateIp = derefString(vnic.PrivateIp)
+		}
+	}
+
+	return privateIp, nil
+}
+
+// flat map of strings describing a node pool, for terraform..
+// nodes are indexed - e.g. publicIp.0, privateIp.0 - & ips are also given as comma separated lists.
+// privateIps are in node order, & a node without one is given an empty privateIp..
+func tfNodePoolResult(nodePool containerengine.NodePool, cluster containerengine.Cluster, privateIps []string, kubeconfigPath string) map[string]string {
+	result := map[string]string{
+		"nodePoolId":        derefString(nodePool.Id),
+		"nodePoolName":      derefString(nodePool.Name),
+		"clusterId":         derefString(nodePool.ClusterId),
+		"clusterName":       derefString(cluster.Name),
+		"compartmentId":     derefString(nodePool.CompartmentId),
+		"kubernetesVersion": derefString(nodePool.KubernetesVersion),
+		"nodeCount":         strconv.Itoa(len(nodePool.Nodes)),
+		"kubeconfig":        kubeconfigPath,
+	}
+	if cluster.Endpoints != nil {
+		result["kubernetesEndpoint"] = derefString(cluster.Endpoints.Kubernetes)
+	}
+
+	publicIps := []string{}
+	for i, node := range nodePool.Nodes {
+		index := strconv.Itoa(i)
+		result["nodeId."+index] = derefString(node.Id)
+		result["nodeName."+index] = derefString(node.Name)
+		result["nodeState."+index] = string(node.LifecycleState)
+		result["publicIp."+index] = derefString(node.PublicIp)
+		result["privateIp."+index] = ""
+		if i < len(privateIps) {
+			result["privateIp."+index] = privateIps[i]
+		}
+		publicIps = append(publicIps, derefString(node.PublicIp))
+	}
+	result["publicIps"] = strings.Join(publicIps, ",")
+	result["privateIps"] = strings.Join(privateIps, ",")
+
+	// first node public ip, as returned by earlier versions..
+	result["workerNodeIp"] = ""
+	if len(publicIps) > 0 {
+		result["workerNodeIp"] = publicIps[0]
+	}
+
+	return result
+}
+
+// write a terraform external data source result to stdout..
+func writeTfResult(w io.Writer, result map[string]string) error {
+	content, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(content))
+	return err
+}
+
+// absolute path, for results consumed outside the working directory..
+func absPath(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	return abs
+}
@@ -0,0 +1,130 @@
+package main
+
+// import libraries..
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// api version of ExecCredentials, unless kubectl asks for another..
+const defaultExecCredentialApiVersion = "client.authentication.k8s.io/v1beta1"
+
+// cachedToken is a cluster token kept in <configDir>/tokens until it nears expiry..
+type cachedToken struct {
+	Token               string    `json:"token"`
+	ExpirationTimestamp time.Time `json:"expirationTimestamp"`
+}
+
+// token for a cluster, from the cache or freshly issued when the cached token expires within renewBefore..
+func clusterToken(ctx context.Context, client okeBackend, configDirPath, clusterId string, expiration, renewBefore time.Duration) (cachedToken, error) {
+	cachePath := filepath.Join(configDirPath, "tokens", strings.NewReplacer("/", "_", "\\", "_").Replace(clusterId)+".json")
+
+	// cached token..
+	cached := cachedToken{}
+	content, err := ioutil.ReadFile(cachePath)
+	if err == nil && json.Unmarshal(content, &cached) == nil && cached.Token != "" && time.Until(cached.ExpirationTimestamp) > renewBefore {
+		return cached, nil
+	}
+
+	// new token..
+	credentials, err := renewKubeconfigCredentials(ctx, client, clusterId, expiration)
+	if err != nil {
+		return cachedToken{}, err
+	}
+	token, _ := mapValue(credentials, "token").(string)
+	if token == "" {
+		return cachedToken{}, fmt.Errorf("kubeconfig for cluster %s holds no token", clusterId)
+	}
+	expires, ok := tokenExpiry(token)
+	if !ok {
+		expires = time.Now().Add(expiration).UTC().Truncate(time.Second)
+	}
+	cached = cachedToken{Token: token, ExpirationTimestamp: expires}
+
+	content, _ = json.MarshalIndent(cached, "", "\t")
+	err = writeFileAtomic(cachePath, content)
+	if err != nil {
+		logln("OKECTL :: Error Writing token cache:", err)
+	}
+
+	return cached, nil
+}
+
+// write a token as an ExecCredential, in the api version named by KUBERNETES_EXEC_INFO if kubectl set it..
+func writeExecCredential(w io.Writer, token cachedToken, execInfo string) error {
+	apiVersion := defaultExecCredentialApiVersion
+	info := struct {
+		ApiVersion string `json:"apiVersion"`
+	}{}
+	if execInfo != "" && json.Unmarshal([]byte(execInfo), &info) == nil && info.ApiVersion != "" {
+		apiVersion = info.ApiVersion
+	}
+
+	content, err := json.MarshalIndent(map[string]interface{}{
+		"kind":       "ExecCredential",
+		"apiVersion": apiVersion,
+		"spec":       map[string]interface{}{},
+		"status": map[string]interface{}{
+			"token":               token.Token,
+			"expirationTimestamp": token.ExpirationTimestamp.UTC().Format(time.RFC3339),
+		},
+	}, "", "\t")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(content))
+	return err
+}
+
+// kubeconfig user that runs `okectl token` for a cluster..
+// configDir & backend are passed on where they differ from the defaults..
+func execKubeconfigUser(clusterId, configDir, backend string) yaml.MapSlice {
+	command, err := os.Executable()
+	if err != nil {
+		command = absPath(os.Args[0])
+	}
+
+	args := []string{}
+	if configDir != ".okectl" {
+		args = append(args, "--configDir="+absPath(configDir))
+	}
+	if backend != "oci" {
+		args = append(args, "--backend="+backend)
+	}
+	args = append(args, "token", "--clusterId="+clusterId)
+
+	return yaml.MapSlice{{Key: "exec", Value: yaml.MapSlice{
+		{Key: "apiVersion", Value: defaultExecCredentialApiVersion},
+		{Key: "command", Value: command},
+		{Key: "args", Value: args},
+	}}}
+}
+
+// rewrite a kubeconfig file so every user runs `okectl token` for the cluster..
+func useExecKubeconfigUsers(path, clusterId, configDir, backend string) error {
+	kubeconfig, err := readKubeconfigMap(path)
+	if err != nil {
+		return err
+	}
+
+	users := []string{}
+	for _, item := range listValue(mapSliceValue(kubeconfig, "users")) {
+		name, _ := mapValue(item, "name").(string)
+		users = append(users, name)
+	}
+	content, err := yaml.Marshal(replaceKubeconfigUsers(kubeconfig, users, execKubeconfigUser(clusterId, configDir, backend)))
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(path, content)
+}
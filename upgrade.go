@@ -0,0 +1,297 @@
+package main
+
+// import libraries..
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/common"
+	"github.com/oracle/oci-go-sdk/containerengine"
+	"github.com/oracle/oci-go-sdk/core"
+	"github.com/oracle/oci-go-sdk/example/helpers"
+)
+
+// parse kubernetes version - e.g. v1.10.3..
+func parseKubeVersion(version string) (major, minor, patch int, ok bool) {
+	parts := strings.Split(strings.TrimPrefix(version, "v"), ".")
+	if len(parts) != 3 {
+		return 0, 0, 0, false
+	}
+
+	numbers := []int{}
+	for _, part := range parts {
+		number, err := strconv.Atoi(part)
+		if err != nil {
+			return 0, 0, 0, false
+		}
+		numbers = append(numbers, number)
+	}
+
+	return numbers[0], numbers[1], numbers[2], true
+}
+
+// compare kubernetes versions, returning -1, 0 or 1..
+// versions which can not be parsed are compared as strings..
+func compareKubeVersions(a, b string) int {
+	aMajor, aMinor, aPatch, aOk := parseKubeVersion(a)
+	bMajor, bMinor, bPatch, bOk := parseKubeVersion(b)
+	if !aOk || !bOk {
+		return strings.Compare(a, b)
+	}
+
+	for _, diff := range []int{aMajor - bMajor, aMinor - bMinor, aPatch - bPatch} {
+		if diff < 0 {
+			return -1
+		}
+		if diff > 0 {
+			return 1
+		}
+	}
+
+	return 0
+}
+
+// choose & check the control plane upgrade target..
+// with no target version, the lowest available upgrade is chosen. unless allowSkipMinor is set, the
+// target may be at most one minor version ahead of the current version..
+func checkClusterUpgrade(currentVersion, targetVersion string, availableUpgrades []string, allowSkipMinor bool) (string, error) {
+	if len(availableUpgrades) == 0 {
+		return "", fmt.Errorf("no upgrades are available for cluster version %s", currentVersion)
+	}
+
+	// default to the lowest available upgrade..
+	if targetVersion == "" {
+		targetVersion = availableUpgrades[0]
+		for _, version := range availableUpgrades {
+			if compareKubeVersions(version, targetVersion) < 0 {
+				targetVersion = version
+			}
+		}
+	}
+
+	// target must be offered by the service..
+	offered := false
+	for _, version := range availableUpgrades {
+		if version == targetVersion {
+			offered = true
+		}
+	}
+	if !offered {
+		return "", fmt.Errorf("version %s is not an available upgrade for cluster version %s - available upgrades: %s",
+			targetVersion, currentVersion, strings.Join(availableUpgrades, ", "))
+	}
+
+	// refuse to skip minor versions..
+	currentMajor, currentMinor, _, currentOk := parseKubeVersion(currentVersion)
+	targetMajor, targetMinor, _, targetOk := parseKubeVersion(targetVersion)
+	if !allowSkipMinor && currentOk && targetOk && (targetMajor != currentMajor || targetMinor > currentMinor+1) {
+		return "", fmt.Errorf("upgrading from %s to %s skips a minor version - upgrade one minor version at a time, or specify --allowSkipMinor",
+			currentVersion, targetVersion)
+	}
+
+	return targetVersion, nil
+}
+
+// upgrade cluster control plane..
+func upgradeCluster(ctx context.Context, client okeBackend, clusterId, kubeVersion string) containerengine.UpdateClusterResponse {
+
+	req := containerengine.UpdateClusterRequest{
+		ClusterId: common.String(clusterId),
+	}
+	req.KubernetesVersion = common.String(kubeVersion)
+
+	logln("OKECTL :: Upgrade Cluster :: Submitted ...")
+	resp, err := client.UpdateCluster(ctx, req)
+	helpers.FatalIfError(err)
+
+	return resp
+}
+
+// node pools running a kubernetes version behind the cluster..
+func outdatedNodePools(ctx context.Context, client okeBackend, compartmentId, clusterId, clusterVersion string) []containerengine.NodePoolSummary {
+	outdated := []containerengine.NodePoolSummary{}
+	for _, nodePool := range listNodePools(ctx, client, compartmentId, clusterId) {
+		if compareKubeVersions(derefString(nodePool.KubernetesVersion), clusterVersion) < 0 {
+			outdated = append(outdated, nodePool)
+		}
+	}
+
+	return outdated
+}
+
+// set node pool kubernetes version..
+// existing nodes keep their version until they are replaced..
+func upgradeNodePool(ctx context.Context, client okeBackend, nodePoolId, kubeVersion string) containerengine.UpdateNodePoolResponse {
+
+	req := containerengine.UpdateNodePoolRequest{
+		NodePoolId: common.String(nodePoolId),
+	}
+	req.KubernetesVersion = common.String(kubeVersion)
+
+	logln("OKECTL :: Update NodePool :: Submitted ...")
+	resp, err := client.UpdateNodePool(ctx, req)
+	helpers.FatalIfError(err)
+
+	return resp
+}
+
+// replace worker nodes in batches, so they come back at the node pool kubernetes version..
+// each node in a batch is cordoned & drained, then its instance terminated & replaced by OKE. the next
+// batch starts once the replacements are ACTIVE & Ready in kubernetes. on failure, nodes cordoned but not
+// terminated are uncordoned, so they take pods again..
+func rollNodePool(
+	ctx context.Context,
+	client okeBackend,
+	compute computeBackend,
+	kube kubeNodeClient,
+	nodePoolId string,
+	batchSize int,
+	drainTimeout, nodeTimeout, pollInterval, maxPollInterval time.Duration) (err error) {
+
+	// names of nodes cordoned & not yet terminated..
+	cordoned := []string{}
+	defer func() {
+		if err != nil {
+			uncordonNodes(kube, cordoned)
+		}
+	}()
+
+	resp, err := client.GetNodePool(ctx, containerengine.GetNodePoolRequest{NodePoolId: common.String(nodePoolId)})
+	if err != nil {
+		return err
+	}
+	nodes := []containerengine.Node{}
+	for _, node := range resp.Nodes {
+		if node.LifecycleState != containerengine.NodeLifecycleStateDeleting && node.LifecycleState != containerengine.NodeLifecycleStateDeleted {
+			nodes = append(nodes, node)
+		}
+	}
+
+	terminated := map[string]bool{}
+	for start := 0; start < len(nodes); start += batchSize {
+		end := start + batchSize
+		if end > len(nodes) {
+			end = len(nodes)
+		}
+		batch := nodes[start:end]
+
+		// cordon & drain..
+		for _, node := range batch {
+			name, err := kube.findNode(node)
+			if err != nil {
+				return err
+			}
+			logln("OKECTL :: Upgrade NodePool :: Cordon & Drain Node", name, "...")
+			err = kube.cordonNode(name)
+			if err != nil {
+				return fmt.Errorf("cordoning node %s: %v", name, err)
+			}
+			cordoned = append(cordoned, name)
+			err = kube.drainNode(ctx, name, drainTimeout)
+			if err != nil {
+				return fmt.Errorf("draining node %s: %v", name, err)
+			}
+		}
+
+		// terminate..
+		for _, node := range batch {
+			logln("OKECTL :: Upgrade NodePool :: Terminate Node", derefString(node.Name), "("+derefString(node.Id)+") ...")
+			_, err := compute.TerminateInstance(ctx, core.TerminateInstanceRequest{InstanceId: node.Id})
+			if err != nil {
+				return fmt.Errorf("terminating node %s: %v", derefString(node.Id), err)
+			}
+			terminated[*node.Id] = true
+			// nodes are terminated in the order they were cordoned..
+			cordoned = cordoned[1:]
+		}
+
+		// wait for replacements..
+		err := waitUntilNodesReplaced(ctx, client, kube, nodePoolId, len(nodes), terminated, nodeTimeout, pollInterval, maxPollInterval)
+		if err != nil {
+			return err
+		}
+		logln("OKECTL :: Upgrade NodePool ::", end, "of", len(nodes), "Node(s) Replaced ...")
+	}
+
+	return nil
+}
+
+// uncordon nodes left cordoned by a failed roll..
+func uncordonNodes(kube kubeNodeClient, names []string) {
+	for _, name := range names {
+		logln("OKECTL :: Upgrade NodePool :: Uncordon Node", name, "...")
+		err := kube.uncordonNode(name)
+		if err != nil {
+			logln("OKECTL :: Upgrade NodePool :: Error uncordoning node", name+":", err)
+		}
+	}
+}
+
+// wait until the node pool is back to size, with every node ACTIVE & Ready in kubernetes..
+// terminated nodes still reported by the node pool are not counted. polls back off as nodeWaiter's do..
+func waitUntilNodesReplaced(
+	ctx context.Context,
+	client okeBackend,
+	kube kubeNodeClient,
+	nodePoolId string,
+	size int,
+	terminated map[string]bool,
+	timeout, pollInterval, maxPollInterval time.Duration) error {
+
+	if pollInterval <= 0 {
+		pollInterval = 15 * time.Second
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		resp, err := client.GetNodePool(ctx, containerengine.GetNodePoolRequest{NodePoolId: common.String(nodePoolId)})
+		if err != nil {
+			return err
+		}
+
+		// count ready nodes, failing fast on nodes OKE could not provision..
+		ready := 0
+		for _, node := range resp.Nodes {
+			if terminated[derefString(node.Id)] {
+				continue
+			}
+			if node.LifecycleState == containerengine.NodeLifecycleStateFailing {
+				message := derefString(node.LifecycleDetails)
+				if node.NodeError != nil {
+					message = derefString(node.NodeError.Message)
+				}
+				return fmt.Errorf("replacement node %s failed: %s", derefString(node.Name), message)
+			}
+			if node.LifecycleState != containerengine.NodeLifecycleStateActive {
+				continue
+			}
+			name, err := kube.findNode(node)
+			if err != nil {
+				continue
+			}
+			nodeReady, err := kube.nodeReady(name)
+			if err == nil && nodeReady {
+				ready++
+			}
+		}
+		if ready >= size {
+			return nil
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return fmt.Errorf("timed out after %s waiting for replacement nodes - %d of %d ready", timeout, ready, size)
+		}
+		sleep := pollInterval
+		if remaining < sleep {
+			sleep = remaining
+		}
+		err = sleepContext(ctx, sleep)
+		if err != nil {
+			return err
+		}
+		pollInterval = backOff(pollInterval, maxPollInterval)
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+// import libraries..
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/containerengine"
+)
+
+// versions compare numerically, falling back to strings when unparseable..
+func TestCompareKubeVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"v1.10.3", "v1.10.3", 0},
+		{"v1.10.3", "v1.10.11", -1},
+		{"v1.11.1", "v1.10.11", 1},
+		{"v1.9.11", "v1.10.3", -1},
+		{"v2.0.0", "v1.13.5", 1},
+		{"1.12.7", "v1.12.7", 0},
+		{"v1.12", "v1.12.7", -1},
+		{"latest", "v1.12.7", -1},
+	}
+	for _, test := range tests {
+		if got := compareKubeVersions(test.a, test.b); got != test.want {
+			t.Errorf("compareKubeVersions(%q, %q) = %d, want %d", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+// the upgrade target defaults to the lowest offered, & minor versions are not skipped..
+func TestCheckClusterUpgrade(t *testing.T) {
+	tests := []struct {
+		name           string
+		current        string
+		target         string
+		available      []string
+		allowSkipMinor bool
+		want           string
+		wantErr        bool
+	}{
+		{"default lowest", "v1.10.3", "", []string{"v1.11.5", "v1.10.11", "v1.11.1"}, false, "v1.10.11", false},
+		{"patch upgrade", "v1.10.3", "v1.10.11", []string{"v1.10.11", "v1.11.1"}, false, "v1.10.11", false},
+		{"next minor", "v1.10.3", "v1.11.5", []string{"v1.10.11", "v1.11.5"}, false, "v1.11.5", false},
+		{"skip minor refused", "v1.10.3", "v1.12.7", []string{"v1.11.5", "v1.12.7"}, false, "", true},
+		{"skip minor allowed", "v1.10.3", "v1.12.7", []string{"v1.11.5", "v1.12.7"}, true, "v1.12.7", false},
+		{"default skips minor", "v1.10.3", "", []string{"v1.12.7", "v1.13.5"}, false, "", true},
+		{"major refused", "v1.13.5", "v2.0.0", []string{"v2.0.0"}, false, "", true},
+		{"not offered", "v1.10.3", "v1.11.2", []string{"v1.11.1", "v1.11.5"}, false, "", true},
+		{"none available", "v1.13.5", "", []string{}, false, "", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := checkClusterUpgrade(test.current, test.target, test.available, test.allowSkipMinor)
+			if (err != nil) != test.wantErr || got != test.want {
+				t.Fatalf("checkClusterUpgrade = %q, %v, want %q, error %t", got, err, test.want, test.wantErr)
+			}
+		})
+	}
+}
+
+// recordingKubeClient records cordons & uncordons, failing the drain of one node..
+type recordingKubeClient struct {
+	fakeKubeClient
+	failDrain  string
+	notReady   bool
+	cordoned   []string
+	uncordoned []string
+}
+
+func (k *recordingKubeClient) cordonNode(name string) error {
+	k.cordoned = append(k.cordoned, name)
+	return nil
+}
+
+func (k *recordingKubeClient) uncordonNode(name string) error {
+	k.uncordoned = append(k.uncordoned, name)
+	return nil
+}
+
+func (k *recordingKubeClient) drainNode(ctx context.Context, name string, timeout time.Duration) error {
+	if name == k.failDrain {
+		return fmt.Errorf("drain of %s refused", name)
+	}
+	return nil
+}
+
+func (k *recordingKubeClient) nodeReady(name string) (bool, error) {
+	return !k.notReady, nil
+}
+
+// a failed roll uncordons the nodes it cordoned but did not terminate..
+func TestRollNodePoolUncordonsOnFailure(t *testing.T) {
+	active := containerengine.NodeLifecycleStateActive
+	tests := []struct {
+		name           string
+		kube           *recordingKubeClient
+		wantErr        bool
+		wantUncordoned []string
+	}{
+		{"success", &recordingKubeClient{}, false, nil},
+		{"drain fails in first batch", &recordingKubeClient{failDrain: "oke-test-000001"}, true, []string{"oke-test-000000", "oke-test-000001"}},
+		{"drain fails in second batch", &recordingKubeClient{failDrain: "oke-test-000002"}, true, []string{"oke-test-000002"}},
+		{"replacements not ready", &recordingKubeClient{notReady: true}, true, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := newFakeBackend("")
+			nodePool := testWaitNodePool(2, active, active, active, active)
+			client.state.NodePools[*nodePool.Id] = nodePool
+			// replacement ids follow the test nodes'..
+			client.state.Sequence = len(nodePool.Nodes)
+
+			err := rollNodePool(context.Background(), client, client, test.kube, *nodePool.Id, 2, time.Second, 50*time.Millisecond, time.Millisecond, time.Millisecond)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("rollNodePool = %v, want error %t", err, test.wantErr)
+			}
+			if !reflect.DeepEqual(test.kube.uncordoned, test.wantUncordoned) {
+				t.Fatalf("uncordoned %v, want %v - cordoned %v", test.kube.uncordoned, test.wantUncordoned, test.kube.cordoned)
+			}
+		})
+	}
+}
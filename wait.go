@@ -0,0 +1,236 @@
+package main
+
+// import libraries..
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/common"
+	"github.com/oracle/oci-go-sdk/containerengine"
+)
+
+// exit codes for node waits & work requests, distinct from the general failure code 3..
+const (
+	exitNodesFailed       = 4
+	exitNodesTimeout      = 5
+	exitWorkRequestFailed = 6
+)
+
+// help text shared by the --waitNodesActive flags..
+const waitNodesActiveHelp = "If waitNodesActive=all, wait & return when all nodes in the pool are active. " +
+	"If waitNodesActive=any, wait & return when any of the nodes in the pool are active. " +
+	"If waitNodesActive=N (e.g. 3), wait until at least N nodes are active. " +
+	"If waitNodesActive=N% (e.g. 75%), wait until at least N percent of nodes are active. " +
+	"If waitNodesActive=false, no wait & return when the node pool is active."
+
+// nodeWaitThreshold is how many nodes must be active before a wait returns..
+type nodeWaitThreshold struct {
+	mode  string // false, all, any, count or percent..
+	value int
+}
+
+// parse a --waitNodesActive value..
+func parseNodeWaitThreshold(waitNodesActive string) (nodeWaitThreshold, error) {
+	switch waitNodesActive {
+	case "", "false":
+		return nodeWaitThreshold{mode: "false"}, nil
+	case "all":
+		return nodeWaitThreshold{mode: "all"}, nil
+	case "any":
+		return nodeWaitThreshold{mode: "any"}, nil
+	}
+
+	if strings.HasSuffix(waitNodesActive, "%") {
+		percent, err := strconv.Atoi(strings.TrimSuffix(waitNodesActive, "%"))
+		if err != nil || percent < 1 || percent > 100 {
+			return nodeWaitThreshold{}, fmt.Errorf("waitNodesActive percentage must be between 1%% & 100%%, not %q", waitNodesActive)
+		}
+		return nodeWaitThreshold{mode: "percent", value: percent}, nil
+	}
+
+	count, err := strconv.Atoi(waitNodesActive)
+	if err != nil || count < 1 {
+		return nodeWaitThreshold{}, fmt.Errorf("waitNodesActive must be all, any, false, a node count or a percentage, not %q", waitNodesActive)
+	}
+	return nodeWaitThreshold{mode: "count", value: count}, nil
+}
+
+// number of active nodes required from a pool of total nodes..
+func (t nodeWaitThreshold) required(total int) int {
+	switch t.mode {
+	case "all":
+		return total
+	case "any":
+		if total > 0 {
+			return 1
+		}
+		return 0
+	case "count":
+		return t.value
+	case "percent":
+		return (total*t.value + 99) / 100
+	}
+	return 0
+}
+
+// nodeWaiter polls a node pool until enough of its nodes are active..
+// the poll interval grows by half on each poll, up to MaxPollInterval. a Timeout of zero waits indefinitely..
+type nodeWaiter struct {
+	WaitNodesActive string
+	Timeout         time.Duration
+	PollInterval    time.Duration
+	MaxPollInterval time.Duration
+	Progress        bool
+}
+
+// nodesFailedError reports nodes OKE could not provision..
+type nodesFailedError struct {
+	nodes []containerengine.Node
+}
+
+func (e nodesFailedError) Error() string {
+	failures := []string{}
+	for _, node := range e.nodes {
+		message := derefString(node.LifecycleDetails)
+		if node.NodeError != nil {
+			message = derefString(node.NodeError.Code) + ": " + derefString(node.NodeError.Message)
+		}
+		failures = append(failures, fmt.Sprintf("%s (%s) %s", derefString(node.Name), node.LifecycleState, message))
+	}
+	return fmt.Sprintf("%d node(s) failed - %s", len(e.nodes), strings.Join(failures, "; "))
+}
+
+// nodesTimeoutError reports a wait that ran out of time..
+type nodesTimeoutError struct {
+	timeout  time.Duration
+	active   int
+	required int
+	states   string
+}
+
+func (e nodesTimeoutError) Error() string {
+	return fmt.Sprintf("timed out after %s with %d of %d required node(s) active (%s)", e.timeout, e.active, e.required, e.states)
+}
+
+// wait until the threshold of nodes is active, returning the node pool's nodes..
+// returns nodesFailedError as soon as any node fails, & nodesTimeoutError when the timeout expires..
+func (w nodeWaiter) wait(ctx context.Context, client okeBackend, nodePoolId string) ([]containerengine.Node, error) {
+	threshold, err := parseNodeWaitThreshold(w.WaitNodesActive)
+	if err != nil {
+		return nil, err
+	}
+	if threshold.mode == "false" {
+		return nil, nil
+	}
+
+	pollInterval := w.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = 15 * time.Second
+	}
+	deadline := time.Now().Add(w.Timeout)
+	for {
+		resp, err := client.GetNodePool(ctx, containerengine.GetNodePoolRequest{NodePoolId: common.String(nodePoolId)})
+		if err != nil {
+			return nil, err
+		}
+
+		// tally nodes, leaving out those being removed..
+		nodes := []containerengine.Node{}
+		failed := []containerengine.Node{}
+		active := 0
+		for _, node := range resp.Nodes {
+			switch {
+			case node.LifecycleState == containerengine.NodeLifecycleStateDeleting || node.LifecycleState == containerengine.NodeLifecycleStateDeleted:
+				continue
+			case node.LifecycleState == containerengine.NodeLifecycleStateFailing || node.NodeError != nil:
+				failed = append(failed, node)
+			case node.LifecycleState == containerengine.NodeLifecycleStateActive:
+				active++
+			}
+			nodes = append(nodes, node)
+		}
+		if len(failed) > 0 {
+			return resp.Nodes, nodesFailedError{nodes: failed}
+		}
+
+		// size the pool from its spec, as nodes may not be listed yet on scale up, & may not be removed yet on scale down..
+		total := len(nodes)
+		if resp.QuantityPerSubnet != nil {
+			total = *resp.QuantityPerSubnet * len(resp.SubnetIds)
+		}
+		required := threshold.required(total)
+		if required > total {
+			return resp.Nodes, fmt.Errorf("waitNodesActive=%s requires %d node(s), but the node pool has %d", w.WaitNodesActive, required, total)
+		}
+
+		// nodes beyond the spec are yet to be removed, & any of them may be active - wait until they are gone..
+		if len(nodes) <= total && active >= required {
+			return resp.Nodes, nil
+		}
+
+		if w.Progress {
+			logln("OKECTL :: Waiting for Node(s) ::", active, "of", required, "required active ::", summariseNodeStates(nodes))
+		}
+		sleep := pollInterval
+		if w.Timeout > 0 {
+			remaining := time.Until(deadline)
+			if remaining <= 0 {
+				return resp.Nodes, nodesTimeoutError{timeout: w.Timeout, active: active, required: required, states: summariseNodeStates(nodes)}
+			}
+			if remaining < sleep {
+				sleep = remaining
+			}
+		}
+		err = sleepContext(ctx, sleep)
+		if err != nil {
+			return resp.Nodes, err
+		}
+		pollInterval = backOff(pollInterval, w.MaxPollInterval)
+	}
+}
+
+// sleep, returning the context's error as soon as it is cancelled..
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// grow a poll interval by half, up to maxPollInterval - where set..
+func backOff(pollInterval, maxPollInterval time.Duration) time.Duration {
+	pollInterval += pollInterval / 2
+	if maxPollInterval > 0 && pollInterval > maxPollInterval {
+		pollInterval = maxPollInterval
+	}
+
+	return pollInterval
+}
+
+// wait until worker nodes are active, exiting when nodes fail or the wait times out..
+func waitUntilNodesActive(ctx context.Context, client okeBackend, nodePoolId string, waiter nodeWaiter) {
+	_, err := waiter.wait(ctx, client, nodePoolId)
+	waitIfInterrupted(ctx)
+	switch err.(type) {
+	case nil:
+		return
+	case nodesFailedError:
+		logln("OKECTL :: Wait for Node(s) :: NodePool", nodePoolId, "::", err, ":: Exiting..")
+		os.Exit(exitNodesFailed)
+	case nodesTimeoutError:
+		logln("OKECTL :: Wait for Node(s) :: NodePool", nodePoolId, "::", err, ":: Exiting..")
+		os.Exit(exitNodesTimeout)
+	default:
+		logln("OKECTL :: Wait for Node(s) :: NodePool", nodePoolId, "::", err, ":: Exiting..")
+		os.Exit(3)
+	}
+}
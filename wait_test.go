@@ -0,0 +1,71 @@
+package main
+
+// import libraries..
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/common"
+	"github.com/oracle/oci-go-sdk/containerengine"
+)
+
+// fake node pool over 2 subnets, with nodes in the given states..
+func testWaitNodePool(quantityPerSubnet int, states ...containerengine.NodeLifecycleStateEnum) *containerengine.NodePool {
+	subnetIds := []string{"ocid1.subnet.oc1.fake.w1", "ocid1.subnet.oc1.fake.w2"}
+	nodes := []containerengine.Node{}
+	for i, state := range states {
+		nodes = append(nodes, containerengine.Node{
+			Id:             common.String(fmt.Sprintf("ocid1.instance.oc1.fake.%06d", i)),
+			Name:           common.String(fmt.Sprintf("oke-test-%06d", i)),
+			SubnetId:       common.String(subnetIds[i%len(subnetIds)]),
+			LifecycleState: state,
+		})
+	}
+
+	return &containerengine.NodePool{
+		Id:                common.String("ocid1.nodepool.oc1.fake.test"),
+		Name:              common.String("test"),
+		QuantityPerSubnet: common.Int(quantityPerSubnet),
+		SubnetIds:         subnetIds,
+		Nodes:             nodes,
+	}
+}
+
+// node waits are sized from the pool spec, not the nodes listed..
+func TestNodeWaiterWait(t *testing.T) {
+	active, creating, deleting := containerengine.NodeLifecycleStateActive, containerengine.NodeLifecycleStateCreating, containerengine.NodeLifecycleStateDeleting
+	tests := []struct {
+		name            string
+		nodePool        *containerengine.NodePool
+		waitNodesActive string
+		wantTimeout     bool
+	}{
+		{"all active", testWaitNodePool(1, active, active), "all", false},
+		{"creating becomes active", testWaitNodePool(1, creating, active), "all", false},
+		{"empty pool", testWaitNodePool(0), "all", false},
+		{"empty pool, any", testWaitNodePool(0), "any", false},
+		{"scaled to 0, nodes deleting", testWaitNodePool(0, deleting, deleting), "all", false},
+		{"scaled to 0, nodes still active", testWaitNodePool(0, active, active), "all", true},
+		{"scaled down, nodes still active", testWaitNodePool(1, active, active, active, active), "all", true},
+		{"scaled down, surplus deleting", testWaitNodePool(1, active, active, deleting, deleting), "all", false},
+		{"scaled down, surplus active, 50%", testWaitNodePool(2, active, active, active, active, active, active), "50%", true},
+		{"scaled down, surplus active, count", testWaitNodePool(1, active, active, active), "1", true},
+		{"not yet listed", testWaitNodePool(2, active, active), "all", true},
+		{"not yet listed, any", testWaitNodePool(2, active), "any", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := newFakeBackend("")
+			client.state.NodePools[*test.nodePool.Id] = test.nodePool
+
+			waiter := nodeWaiter{WaitNodesActive: test.waitNodesActive, Timeout: 20 * time.Millisecond, PollInterval: time.Millisecond}
+			_, err := waiter.wait(context.Background(), client, *test.nodePool.Id)
+			_, timedOut := err.(nodesTimeoutError)
+			if timedOut != test.wantTimeout || (err != nil && !timedOut) {
+				t.Fatalf("wait(%s) = %v, want timeout %t", test.waitNodesActive, err, test.wantTimeout)
+			}
+		})
+	}
+}
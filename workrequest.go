@@ -0,0 +1,101 @@
+package main
+
+// import libraries..
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/common"
+	"github.com/oracle/oci-go-sdk/containerengine"
+	"github.com/oracle/oci-go-sdk/example/helpers"
+)
+
+// workRequestFailedError reports a work request that finished FAILED or CANCELED, with its errors & logs..
+type workRequestFailedError struct {
+	workRequest containerengine.WorkRequest
+	errors      []containerengine.WorkRequestError
+	logs        []containerengine.WorkRequestLogEntry
+}
+
+func (e workRequestFailedError) Error() string {
+	message := fmt.Sprintf("work request %s (%s) %s", derefString(e.workRequest.Id), e.workRequest.OperationType, e.workRequest.Status)
+	if len(e.errors) > 0 {
+		message += fmt.Sprintf(" - %s: %s", derefString(e.errors[0].Code), derefString(e.errors[0].Message))
+	}
+	return message
+}
+
+// wait until a work request finishes..
+// returns workRequestFailedError when it did not succeed..
+func waitForWorkRequest(ctx context.Context, client okeBackend, workRequestId *string) (containerengine.GetWorkRequestResponse, error) {
+	// retry GetWorkRequest call until TimeFinished is set..
+	shouldRetryFunc := func(r common.OCIOperationResponse) bool {
+		resp, ok := r.Response.(containerengine.GetWorkRequestResponse)
+		return !ok || resp.TimeFinished == nil
+	}
+
+	getWorkReq := containerengine.GetWorkRequestRequest{
+		WorkRequestId:   workRequestId,
+		RequestMetadata: helpers.GetRequestMetadataWithCustomizedRetryPolicy(shouldRetryFunc),
+	}
+
+	done := trackWorkRequest(derefString(workRequestId))
+	defer done()
+
+	getResp, err := client.GetWorkRequest(ctx, getWorkReq)
+	if err != nil {
+		return getResp, err
+	}
+
+	switch getResp.Status {
+	case containerengine.WorkRequestStatusSucceeded:
+		return getResp, nil
+	case containerengine.WorkRequestStatusFailed, containerengine.WorkRequestStatusCanceled:
+		return getResp, workRequestFailure(ctx, client, getResp.WorkRequest)
+	}
+	return getResp, fmt.Errorf("work request %s (%s) did not finish, status %s", derefString(workRequestId), getResp.OperationType, getResp.Status)
+}
+
+// collect the errors & logs of a failed work request..
+func workRequestFailure(ctx context.Context, client okeBackend, workRequest containerengine.WorkRequest) workRequestFailedError {
+	failure := workRequestFailedError{workRequest: workRequest}
+
+	errorsResp, err := client.ListWorkRequestErrors(ctx, containerengine.ListWorkRequestErrorsRequest{
+		CompartmentId: workRequest.CompartmentId,
+		WorkRequestId: workRequest.Id,
+	})
+	if err != nil {
+		logln("OKECTL :: Error listing work request errors:", err)
+	}
+	failure.errors = errorsResp.Items
+
+	logsResp, err := client.ListWorkRequestLogs(ctx, containerengine.ListWorkRequestLogsRequest{
+		CompartmentId: workRequest.CompartmentId,
+		WorkRequestId: workRequest.Id,
+	})
+	if err != nil {
+		logln("OKECTL :: Error listing work request logs:", err)
+	}
+	failure.logs = logsResp.Items
+
+	return failure
+}
+
+// print the errors & logs of a failed work request..
+func printWorkRequestFailure(failure workRequestFailedError) {
+	logln("OKECTL :: Work Request", derefString(failure.workRequest.Id), "::", failure.workRequest.OperationType, failure.workRequest.Status)
+	for _, resource := range failure.workRequest.Resources {
+		logln(" - resource:", derefString(resource.EntityType), derefString(resource.Identifier), resource.ActionType)
+	}
+	for _, workRequestError := range failure.errors {
+		timestamp := ""
+		if workRequestError.Timestamp != nil {
+			timestamp = workRequestError.Timestamp.UTC().Format(time.RFC3339)
+		}
+		logln(" - error:", timestamp, derefString(workRequestError.Code)+":", derefString(workRequestError.Message))
+	}
+	for _, entry := range failure.logs {
+		logln(" - log:", derefString(entry.Timestamp), derefString(entry.Message))
+	}
+}